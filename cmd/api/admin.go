@@ -0,0 +1,33 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"practice-one/internal/middleware"
+)
+
+// newAdminServer serves profiling and expvar diagnostics, and the request
+// metrics at /metrics. It is meant for a private address, so the endpoints
+// are neither authenticated nor exposed on the public listener.
+func newAdminServer(addr string, metrics *middleware.Metrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/metrics", metrics.Handler)
+
+	return &http.Server{
+		Addr:        addr,
+		Handler:     mux,
+		ReadTimeout: 15 * time.Second,
+		// No WriteTimeout: CPU profiles and traces stream for as long as
+		// the client asks (?seconds=).
+		IdleTimeout: 60 * time.Second,
+	}
+}
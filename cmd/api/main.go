@@ -2,70 +2,275 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"practice-one/internal/clock"
+	"practice-one/internal/config"
 	"practice-one/internal/handlers"
+	"practice-one/internal/listener"
 	"practice-one/internal/middleware"
 	"practice-one/internal/router"
 	"practice-one/internal/store"
 )
 
 func main() {
+	cfg := config.Load()
+
 	taskStore := store.NewTaskStore()
+	if cfg.DataFile != "" {
+		var err error
+		taskStore, err = store.NewTaskStoreWithFile(cfg.DataFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Persisting tasks to %s", cfg.DataFile)
+	}
+
+	// With ASSERT_SAFE_METHODS the handlers write through a counting
+	// wrapper so safeMethods can spot GET and HEAD handlers that write.
+	var handlerStore store.Store = taskStore
+	var safeMethods func(http.Handler) http.Handler
+	if cfg.AssertSafeMethods {
+		tracked := store.NewWriteTrackingStore(taskStore)
+		handlerStore = tracked
+		safeMethods = middleware.NewSafeMethodGuard(tracked.Writes).Check
+		log.Printf("Asserting GET and HEAD handlers do not write; requests are serialized")
+	}
 
-	taskHandler := handlers.NewTaskHandler(taskStore)
+	// The expvar counters and request metrics are only visible on the admin
+	// listener, so they are only kept up to date when it is enabled.
+	var metrics *middleware.Metrics
+	var requestCounter, collectMetrics func(http.Handler) http.Handler
+	if cfg.AdminAddr != "" {
+		handlerStore = store.NewCreateCountingStore(handlerStore, tasksCreated)
+		publishTaskVars(taskStore)
+		requestCounter = countRequests
+		metrics = middleware.NewMetrics(clock.Real{})
+		collectMetrics = metrics.Collect
+	}
+
+	switch handlers.TitleCase(cfg.TitleCase) {
+	case handlers.TitleCaseNone, handlers.TitleCaseLower, handlers.TitleCaseTitle:
+	default:
+		log.Fatalf("invalid TITLE_CASE=%q: use lower or title", cfg.TitleCase)
+	}
+
+	taskHandler := handlers.NewTaskHandler(handlerStore,
+		handlers.WithTitleNormalizer(handlers.TitleNormalizer{
+			CollapseSpaces: cfg.TitleCollapseSpaces,
+			Case:           handlers.TitleCase(cfg.TitleCase),
+		}),
+		handlers.WithStrictBoolParams(cfg.StrictBoolParams),
+		handlers.WithPendingByDefault(cfg.ListPendingByDefault),
+		handlers.WithMaxID(cfg.MaxID),
+		handlers.WithStrictQueryParams(cfg.StrictQueryParams),
+		handlers.WithCreateDedupWindow(cfg.CreateDedupWindow),
+		handlers.WithImportTimeout(cfg.ImportTimeout),
+		handlers.WithDefaultContentType(cfg.DefaultContentType),
+	)
+	if !taskHandler.HasSerializer(cfg.DefaultContentType) {
+		log.Printf("No serializer for DEFAULT_CONTENT_TYPE %q; defaulting to application/json", cfg.DefaultContentType)
+	}
 
 	r := router.NewRouter()
+	r.SetMaxBody(int64(cfg.MaxBodyBytes))
 
-	r.GET("/v1/tasks", taskHandler.GetTask)
-	r.POST("/v1/tasks", taskHandler.CreateTask)
-	r.PATCH("/v1/tasks", taskHandler.UpdateTask)
-	r.DELETE("/v1/tasks", taskHandler.DeleteTask)
+	r.GET("/v1/tasks", taskHandler.GetTask).
+		Doc("List or get tasks", "Returns all tasks, or one task when ?id= is given. Filter with ?done=.")
+	r.POST("/v1/tasks", taskHandler.CreateTask).
+		Doc("Create a task", "Creates a task from a JSON body with a title.")
+	r.PATCH("/v1/tasks", taskHandler.UpdateTask).
+		Doc("Update a task", "Updates the fields sent for the task given by ?id=; ?upsert=true creates it if missing. Prefer PATCH /v1/tasks/:id.")
+	r.DELETE("/v1/tasks", taskHandler.DeleteTask).
+		Doc("Delete a task", "Soft-deletes the task given by ?id=. Prefer DELETE /v1/tasks/:id.")
+	r.GET("/v1/tasks/:id", taskHandler.GetTask).
+		Doc("Get a task", "Returns the task with the given id.")
+	r.PATCH("/v1/tasks/:id", taskHandler.UpdateTask).
+		Doc("Update a task", "Updates the fields sent for the task with the given id; ?upsert=true creates it if missing.")
+	r.PUT("/v1/tasks", taskHandler.ReplaceTask).
+		Doc("Replace a task", "Replaces the title and done status of the task given by ?id=. Prefer PUT /v1/tasks/:id.")
+	r.PUT("/v1/tasks/:id", taskHandler.ReplaceTask).
+		Doc("Replace a task", "Replaces the title and done status of the task with the given id.")
+	r.DELETE("/v1/tasks/:id", taskHandler.DeleteTask).
+		Doc("Delete a task", "Soft-deletes the task with the given id; POST /v1/tasks/:id/restore undoes it.")
+	r.POST("/v1/tasks/:id/restore", taskHandler.RestoreTask).
+		Doc("Restore a task", "Undoes the soft delete of the task with the given id.")
+	r.GET("/v1/tasks/export", taskHandler.ExportTasks).
+		Doc("Export tasks", "Exports all tasks as JSON or CSV (?format=csv). Supports Range requests.")
+	r.POST("/v1/tasks/import", taskHandler.ImportTasks).
+		Doc("Import tasks", "Creates tasks from a JSON array, streaming NDJSON results per item.").
+		MaxBody(int64(cfg.ImportMaxBodyBytes))
+	r.POST("/v1/tasks/bulk", taskHandler.BulkCreateTasks).
+		Doc("Create tasks in bulk", "Creates all tasks in a JSON array atomically, or per item with ?partial=true.")
+	r.PATCH("/v1/tasks/bulk", taskHandler.BulkUpdateTasks).
+		Doc("Update tasks in bulk", "Applies each {\"id\", \"version\", ...} update whose version still matches, reporting per-item outcomes.")
+	r.DELETE("/v1/tasks/bulk", taskHandler.BulkDeleteTasks).
+		Doc("Delete tasks in bulk", "Deletes the tasks listed in a JSON body {\"ids\": [...]}, reporting missing ids.")
+	r.POST("/v1/tasks/merge", taskHandler.MergeTasks).
+		Doc("Merge tasks", "Merges the source task into the target and deletes the source.")
+	r.GET("/v1/tasks/search", taskHandler.SearchTasks).
+		Doc("Search tasks", "Searches titles with ?q=, ranking exact, then prefix, then substring matches.")
+	r.GET("/v1/tasks/random", taskHandler.RandomTask).
+		Doc("Pick a random task", "Returns a random pending task; ?pending=false may pick done tasks too.")
+	r.GET("/v1/tasks/count", taskHandler.CountTasks).
+		Doc("Count tasks", "Counts tasks matching the list filters, e.g. ?done=false.")
+	r.GET("/v1/tasks/stats/grouped", taskHandler.GetGroupedStats).
+		Doc("Grouped task counts", "Counts tasks grouped by ?by=done, priority or tag; a task counts once per tag.")
+	r.GET("/v1/_routes", r.RoutesHandler).
+		Doc("List routes", "Lists registered routes with their documentation.")
 
-	r.GET("/health", func(w http.ResponseWriter, r *http.Request) {
+	errorRecorder := middleware.NewErrorRecorder(cfg.ErrorBufferSize, clock.Real{})
+	r.GET("/v1/_admin/errors", errorRecorder.Handler).
+		Doc("Recent server errors", "Lists the most recent 5xx responses, newest first.")
+	r.GET("/v1/_admin/snapshot", taskHandler.Snapshot).
+		Doc("Snapshot store state", "Returns a hash of all tasks that changes whenever their data does.")
+
+	r.GET("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"healthy"}`))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name": "Task API",
+			"links": map[string]string{
+				"health":  "/health",
+				"ready":   "/ready",
+				"tasks":   "/v1/tasks",
+				"export":  "/v1/tasks/export",
+				"routes":  "/v1/_routes",
+				"swagger": "/swagger",
+			},
+		})
 	})
 
 	r.PrintRoutes()
 
-	validAPIKeys := map[string]bool{
-		"secret12345":      true,
-		"dev-key-001":      true,
-		"production-key-1": true,
+	// API keys and the rate limit can be swapped on SIGHUP.
+	apiKeys := middleware.NewAPIKeys(cfg.APIKeys)
+
+	// Optional middlewares stay nil when disabled; Chain skips them.
+	var rateLimiter *middleware.RateLimiter
+	var cors, breaker, timeout, rateLimit, dailyQuota, strictBodies func(http.Handler) http.Handler
+	if len(cfg.CORSOrigins) > 0 {
+		cors = middleware.CORS(cfg.CORSOrigins)
+	}
+	if cfg.BreakerThreshold > 0 {
+		breaker = middleware.NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown, clock.Real{}).Protect
+	}
+	if cfg.RequestTimeout > 0 {
+		// The import streams its results under its own deadline.
+		timeout = middleware.TimeoutExcept(cfg.RequestTimeout, "/v1/tasks/import")
+	}
+	if !cfg.RateLimitDisabled {
+		var keyFunc func(*http.Request) string
+		switch cfg.RateLimitBy {
+		case "ip":
+			// The limiter buckets by client IP when keyFunc is nil.
+		case "key":
+			keyFunc = apiKeys.LimitKey
+		default:
+			log.Fatalf("invalid RATE_LIMIT_BY=%q: use ip or key", cfg.RateLimitBy)
+		}
+		rateLimiter = middleware.NewRateLimiter(cfg.RateLimit,
+			middleware.WithRefillInterval(cfg.RateLimitRefill),
+			middleware.WithKeyFunc(keyFunc),
+			middleware.WithTrustProxy(cfg.TrustProxy),
+		)
+		rateLimit = rateLimiter.Limit
+	}
+	if cfg.DailyQuota > 0 {
+		dailyQuota = middleware.NewDailyQuota(cfg.DailyQuota, clock.Real{}).Limit
+	}
+	if cfg.StrictBodies {
+		strictBodies = middleware.RejectBodyOnGetDeleteExcept("/v1/tasks/bulk")
 	}
 
-	rateLimiter := middleware.NewRateLimiter(10)
+	var logger func(http.Handler) http.Handler
+	switch cfg.LogFormat {
+	case "text":
+		logger = middleware.Logger
+	case "json":
+		logger = middleware.JSONLogger
+	default:
+		log.Fatalf("invalid LOG_FORMAT=%q: use text or json", cfg.LogFormat)
+	}
 
 	handler := middleware.Chain(
-		middleware.Logger,
-		middleware.RequestID,
-		rateLimiter.Limit,
-		middleware.APIKeyAuth(validAPIKeys),
+		requestCounter,
+		logger,
+		collectMetrics,
+		middleware.NewRequestID(),
+		middleware.Trace,
+		errorRecorder.Record,
+		cors,
+		breaker,
+		timeout,
+		rateLimit,
+		apiKeys.Auth,
+		dailyQuota,
+		strictBodies,
+		safeMethods,
 	)(r)
 
+	// /health and /ready are served ahead of the chain; see withProbes.
+	var ready atomic.Bool
+	ready.Store(true)
+	handler = withProbes(handler, &ready, r.ServerOptions)
+
 	srv := &http.Server{
-		Addr:         ":8080",
+		Addr:         cfg.Addr,
 		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+
+		// HTTP/2 is only negotiated over TLS (ALPN); plain HTTP stays 1.1.
+		Protocols: serverProtocols(),
+
+		// Answer "OPTIONS *" with the router's server-wide Allow header.
+		DisableGeneralOptionsHandler: true,
+	}
+
+	var adminSrv *http.Server
+	if cfg.AdminAddr != "" {
+		adminSrv = newAdminServer(cfg.AdminAddr, metrics)
 	}
 
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		current := cfg
+		for range hup {
+			log.Println("Reloading configuration...")
+			current = reloadConfig(current, apiKeys, rateLimiter)
+		}
+	}()
+
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
 	go func() {
 		<-sig
 
+		// Fail readiness first and give load balancers the grace period to
+		// notice before connections start being refused.
+		ready.Store(false)
+		if cfg.ShutdownGrace > 0 {
+			log.Printf("Not ready; waiting %s before shutting down...", cfg.ShutdownGrace)
+			time.Sleep(cfg.ShutdownGrace)
+		}
+
 		shutdownCtx, cancel := context.WithTimeout(serverCtx, 30*time.Second)
 		defer cancel()
 
@@ -77,19 +282,54 @@ func main() {
 		}()
 
 		log.Println("Shutting down server gracefully...")
+		if adminSrv != nil {
+			if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+				log.Printf("admin server shutdown: %v", err)
+			}
+		}
 		err := srv.Shutdown(shutdownCtx)
 		if err != nil {
 			log.Fatal(err)
 		}
+		if rateLimiter != nil {
+			rateLimiter.Stop()
+		}
 		serverStopCtx()
 	}()
 
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		log.Fatal("TLS_CERT and TLS_KEY must be set together")
+	}
+
 	log.Printf("Starting server on %s", srv.Addr)
 	log.Printf("Swagger documentation available at http://localhost:8080/swagger")
 	log.Printf("API v1 endpoints available at /v1/tasks")
-	log.Printf("Valid API keys: secret12345, dev-key-001, production-key-1")
+	log.Printf("Accepting API keys for %v", identities(cfg.APIKeys))
 
-	err := srv.ListenAndServe()
+	ln, err := listen(srv.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.MaxConns > 0 {
+		ln = listener.LimitListener(ln, cfg.MaxConns)
+		log.Printf("Accepting at most %d concurrent connections", cfg.MaxConns)
+	}
+
+	if adminSrv != nil {
+		adminLn, err := net.Listen("tcp", adminSrv.Addr)
+		if err != nil {
+			log.Fatalf("Cannot listen on admin address %s: %v", adminSrv.Addr, err)
+		}
+		log.Printf("Admin diagnostics available on %s", adminSrv.Addr)
+
+		go func() {
+			if err := adminSrv.Serve(adminLn); err != nil && err != http.ErrServerClosed {
+				log.Printf("admin server: %v", err)
+			}
+		}()
+	}
+
+	err = serve(srv, ln, cfg.TLSCert, cfg.TLSKey)
 	if err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
@@ -97,3 +337,32 @@ func main() {
 	<-serverCtx.Done()
 	log.Println("Server stopped gracefully")
 }
+
+// listen opens the API listener on addr. An address that is already in use
+// gets an error saying how to fix it; it still matches syscall.EADDRINUSE.
+func listen(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return nil, fmt.Errorf("cannot listen on %s: address already in use; stop the process using it or set ADDR to a free address: %w", addr, err)
+	}
+	return ln, err
+}
+
+// serverProtocols enables HTTP/1.1 and, for HTTPS, HTTP/2 so clients can
+// multiplex concurrent requests over one connection.
+func serverProtocols() *http.Protocols {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	return &protocols
+}
+
+// serve accepts connections on ln, over HTTPS when a certificate and key
+// are configured and plain HTTP otherwise. Both paths stop on srv.Shutdown.
+func serve(srv *http.Server, ln net.Listener, certFile, keyFile string) error {
+	if certFile != "" {
+		log.Printf("Serving HTTPS with certificate %s", certFile)
+		return srv.ServeTLS(ln, certFile, keyFile)
+	}
+	return srv.Serve(ln)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestListenAddressInUse(t *testing.T) {
+	taken, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	addr := taken.Addr().String()
+
+	ln, err := listen(addr)
+	if err == nil {
+		ln.Close()
+		t.Fatalf("listening on %s twice succeeded", addr)
+	}
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("error %v does not match syscall.EADDRINUSE", err)
+	}
+	for _, want := range []string{addr, "address already in use", "set ADDR"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
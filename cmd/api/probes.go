@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"practice-one/internal/router"
+)
+
+// withProbes answers the health and readiness probes itself and passes every
+// other request to next. Probes skip the middleware chain so they need no API
+// key and are never rate limited, even when the server is saturated.
+// Connection limits (MAX_CONNS) still apply since they act before HTTP.
+// "OPTIONS *" capability checks are answered here by serverOptions too.
+//
+// /ready answers 503 once ready is cleared, so load balancers stop sending
+// traffic before shutdown; /health keeps answering 200.
+func withProbes(next http.Handler, ready *atomic.Bool, serverOptions http.HandlerFunc) http.Handler {
+	probes := map[string]http.HandlerFunc{
+		"/health": probeHandler(`{"status":"healthy"}`),
+		"/ready":  readyHandler(ready),
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if router.IsServerOptions(r) {
+			serverOptions(w, r)
+			return
+		}
+		if probe, ok := probes[r.URL.Path]; ok {
+			probe(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func probeHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, r, http.StatusOK, body)
+	}
+}
+
+func readyHandler(ready *atomic.Bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			writeProbe(w, r, http.StatusServiceUnavailable, `{"status":"draining"}`)
+			return
+		}
+		writeProbe(w, r, http.StatusOK, `{"status":"ready"}`)
+	}
+}
+
+func writeProbe(w http.ResponseWriter, r *http.Request, status int, body string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"maps"
+	"reflect"
+	"sort"
+
+	"practice-one/internal/config"
+	"practice-one/internal/middleware"
+)
+
+// reloadConfig re-reads the configuration and applies the settings that can
+// change at runtime: the API keys and the rate limit. Other settings keep
+// their startup values until the server is restarted. It returns the
+// configuration now in effect.
+func reloadConfig(current *config.Config, apiKeys *middleware.APIKeys, limiter *middleware.RateLimiter) *config.Config {
+	next := config.Load()
+	applied := *current
+
+	if !maps.Equal(current.APIKeys, next.APIKeys) {
+		apiKeys.Set(next.APIKeys)
+		applied.APIKeys = next.APIKeys
+		log.Printf("config reload: API keys changed, identities now %v", identities(next.APIKeys))
+	}
+
+	if limiter != nil && (current.RateLimit != next.RateLimit || current.RateLimitRefill != next.RateLimitRefill) {
+		limiter.SetRate(next.RateLimit, next.RateLimitRefill)
+		applied.RateLimit = next.RateLimit
+		applied.RateLimitRefill = next.RateLimitRefill
+		log.Printf("config reload: rate limit %d/min every %s -> %d/min every %s",
+			current.RateLimit, current.RateLimitRefill, next.RateLimit, next.RateLimitRefill)
+	}
+
+	if !reflect.DeepEqual(&applied, next) {
+		log.Printf("config reload: other settings changed and take effect after a restart")
+	}
+
+	return &applied
+}
+
+func identities(keys map[string]string) []string {
+	names := make([]string, 0, len(keys))
+	for _, name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
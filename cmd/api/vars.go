@@ -0,0 +1,33 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+
+	"practice-one/internal/store"
+)
+
+// Counters published at /debug/vars on the admin listener.
+var (
+	requestsTotal = expvar.NewInt("requests_total")
+	tasksCreated  = expvar.NewInt("tasks_created")
+)
+
+// publishTaskVars reports the number of live tasks in s as tasks_active. It
+// is read from the store on each scrape rather than tracked, so soft deletes,
+// restores and merges need no bookkeeping.
+func publishTaskVars(s store.Store) {
+	expvar.Publish("tasks_active", expvar.Func(func() any {
+		return s.Count(store.Filter{})
+	}))
+}
+
+// countRequests adds every request that reaches the middleware chain to
+// requests_total. Health and readiness probes are served ahead of the chain
+// and are not counted.
+func countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsTotal.Add(1)
+		next.ServeHTTP(w, r)
+	})
+}
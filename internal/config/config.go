@@ -0,0 +1,309 @@
+package config
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the server settings read from the environment. When
+// CONFIG_FILE names a file of KEY=VALUE lines, its values take precedence
+// over the environment; re-running Load picks up edits to that file.
+type Config struct {
+	// Addr is the address the API server listens on. It is not reloadable.
+	Addr string
+	// AdminAddr is the address for pprof and expvar diagnostics. Empty
+	// disables the admin listener. It is not reloadable.
+	AdminAddr string
+	// DataFile is the JSON file tasks are persisted to. Empty keeps tasks
+	// in memory only.
+	DataFile string
+	// MaxConns caps simultaneously accepted TCP connections; further
+	// connections wait until one closes. Zero means unlimited.
+	MaxConns int
+
+	// RateLimit is the number of requests allowed per visitor per minute.
+	RateLimit int
+	// RateLimitRefill is how often a visitor's tokens are topped up.
+	RateLimitRefill time.Duration
+	// RateLimitBy selects the rate limit bucket: "ip" (default) or "key"
+	// for per-API-key limits with the client address as fallback.
+	RateLimitBy string
+	// TrustProxy takes client IPs from X-Forwarded-For/X-Real-IP when rate
+	// limiting by IP. Only enable it behind a proxy that sets them.
+	TrustProxy bool
+	// RateLimitDisabled removes the rate limiter from the middleware chain,
+	// e.g. on trusted internal networks.
+	RateLimitDisabled bool
+
+	// APIKeys maps each accepted X-API-Key to the identity name used in logs.
+	// It is read from API_KEYS as comma-separated key:name pairs.
+	APIKeys map[string]string
+
+	// CORSOrigins are the browser origins allowed to call the API; "*"
+	// allows any. Empty disables CORS headers.
+	CORSOrigins []string
+
+	// DailyQuota is the number of requests each API key may make per UTC
+	// day. Zero disables the quota.
+	DailyQuota int
+
+	// BreakerThreshold is the number of consecutive 5xx responses that trip
+	// the circuit breaker. Zero disables it.
+	BreakerThreshold int
+	// BreakerCooldown is how long the tripped breaker fails fast before
+	// letting a probe request through.
+	BreakerCooldown time.Duration
+
+	// ErrorBufferSize is how many recent 5xx responses are kept for
+	// GET /v1/_admin/errors.
+	ErrorBufferSize int
+
+	// TitleCollapseSpaces collapses runs of whitespace inside task titles.
+	TitleCollapseSpaces bool
+	// TitleCase is the casing applied to task titles: "", "lower" or "title".
+	TitleCase string
+
+	// ListPendingByDefault makes GET /v1/tasks without a done filter return
+	// only pending tasks.
+	ListPendingByDefault bool
+
+	// MaxID is the largest task id accepted in requests; larger ids get a
+	// 400. Zero means no limit.
+	MaxID int
+
+	// CreateDedupWindow makes identical POST /v1/tasks requests from the
+	// same API key within the window return the first task. Zero disables.
+	CreateDedupWindow time.Duration
+
+	// StrictBoolParams only accepts strconv.ParseBool forms for boolean
+	// query parameters, rejecting yes/no and on/off.
+	StrictBoolParams bool
+
+	// StrictBodies rejects GET and DELETE requests that carry a body.
+	StrictBodies bool
+
+	// StrictQueryParams rejects requests with query parameters the endpoint
+	// does not recognize. Clients can opt in per request with
+	// "Prefer: handling=strict" regardless of this setting.
+	StrictQueryParams bool
+
+	// DefaultContentType is the response media type used when a request has
+	// no Accept header. Types without a serializer fall back to JSON.
+	DefaultContentType string
+
+	// AssertSafeMethods logs GET and HEAD requests that write to the store.
+	// It serializes those requests, so it is meant for development only.
+	AssertSafeMethods bool
+
+	// MaxBodyBytes limits request bodies; ImportMaxBodyBytes applies to
+	// POST /v1/tasks/import instead. Zero means unlimited.
+	MaxBodyBytes       int
+	ImportMaxBodyBytes int
+
+	// TLSCert and TLSKey are PEM file paths. When both are set the server
+	// speaks HTTPS on Addr; when both are empty it speaks plain HTTP.
+	TLSCert string
+	TLSKey  string
+
+	// LogFormat selects the request log format: "text" (default) or
+	// "json" for one JSON object per request.
+	LogFormat string
+
+	// ShutdownGrace is how long /ready reports 503 after a shutdown signal
+	// before the server stops accepting connections. Zero shuts down at once.
+	ShutdownGrace time.Duration
+
+	// RequestTimeout is how long a handler may take before the client gets
+	// a 503. Zero disables the timeout.
+	RequestTimeout time.Duration
+	// ImportTimeout replaces RequestTimeout for POST /v1/tasks/import, which
+	// streams its results and may run far longer. Zero means no deadline.
+	ImportTimeout time.Duration
+}
+
+func Load() *Config {
+	src := loadSource()
+
+	return &Config{
+		Addr:                 src.getString("ADDR", ":8080"),
+		AdminAddr:            src.getString("ADMIN_ADDR", ""),
+		DataFile:             src.getString("DATA_FILE", ""),
+		MaxConns:             src.getInt("MAX_CONNS", 0),
+		RateLimit:            src.getInt("RATE_LIMIT", 10),
+		RateLimitRefill:      src.getDuration("RATE_LIMIT_REFILL", time.Second),
+		RateLimitBy:          src.getString("RATE_LIMIT_BY", "ip"),
+		TrustProxy:           src.getBool("TRUST_PROXY", false),
+		RateLimitDisabled:    src.getBool("RATE_LIMIT_DISABLED", false),
+		APIKeys:              src.getKeys("API_KEYS", defaultAPIKeys),
+		CORSOrigins:          src.getList("CORS_ORIGINS"),
+		DailyQuota:           src.getInt("DAILY_QUOTA", 0),
+		BreakerThreshold:     src.getInt("BREAKER_THRESHOLD", 0),
+		BreakerCooldown:      src.getDuration("BREAKER_COOLDOWN", 30*time.Second),
+		ErrorBufferSize:      src.getInt("ERROR_BUFFER_SIZE", 50),
+		TitleCollapseSpaces:  src.getBool("TITLE_COLLAPSE_SPACES", false),
+		TitleCase:            src.getString("TITLE_CASE", ""),
+		ListPendingByDefault: src.getBool("LIST_PENDING_DEFAULT", false),
+		MaxID:                src.getInt("MAX_ID", 0),
+		CreateDedupWindow:    src.getDuration("CREATE_DEDUP_WINDOW", 0),
+		StrictBoolParams:     src.getBool("STRICT_BOOL_PARAMS", false),
+		StrictBodies:         src.getBool("STRICT_BODIES", false),
+		StrictQueryParams:    src.getBool("STRICT_QUERY_PARAMS", false),
+		DefaultContentType:   src.getString("DEFAULT_CONTENT_TYPE", "application/json"),
+		AssertSafeMethods:    src.getBool("ASSERT_SAFE_METHODS", false),
+		MaxBodyBytes:         src.getInt("MAX_BODY_BYTES", 1<<20),
+		ImportMaxBodyBytes:   src.getInt("IMPORT_MAX_BODY_BYTES", 32<<20),
+		TLSCert:              src.getString("TLS_CERT", ""),
+		TLSKey:               src.getString("TLS_KEY", ""),
+		RequestTimeout:       src.getDuration("REQUEST_TIMEOUT", 10*time.Second),
+		ImportTimeout:        src.getDuration("IMPORT_TIMEOUT", 5*time.Minute),
+		LogFormat:            src.getString("LOG_FORMAT", "text"),
+		ShutdownGrace:        src.getDuration("SHUTDOWN_GRACE", 0),
+	}
+}
+
+// defaultAPIKeys are the development keys accepted when API_KEYS is unset.
+var defaultAPIKeys = map[string]string{
+	"secret12345":      "default",
+	"dev-key-001":      "dev",
+	"production-key-1": "production",
+}
+
+// source looks settings up in the CONFIG_FILE values first and then in the
+// environment.
+type source map[string]string
+
+func loadSource() source {
+	path, ok := os.LookupEnv("CONFIG_FILE")
+	if !ok || path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("config: cannot read CONFIG_FILE: %v", err)
+		return nil
+	}
+	defer f.Close()
+
+	src := make(source)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			log.Printf("config: %s:%d: expected KEY=VALUE", path, line)
+			continue
+		}
+		src[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("config: cannot read CONFIG_FILE: %v", err)
+	}
+
+	return src
+}
+
+func (s source) lookup(key string) (string, bool) {
+	if value, ok := s[key]; ok {
+		return value, true
+	}
+	return os.LookupEnv(key)
+}
+
+func (s source) getString(key, fallback string) string {
+	if value, ok := s.lookup(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (s source) getBool(key string, fallback bool) bool {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %v", key, value, fallback)
+		return fallback
+	}
+
+	return b
+}
+
+func (s source) getInt(key string, fallback int) int {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+
+	return n
+}
+
+func (s source) getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %s", key, value, fallback)
+		return fallback
+	}
+
+	return d
+}
+
+// getList splits a comma-separated value, dropping empty entries.
+func (s source) getList(key string) []string {
+	value, _ := s.lookup(key)
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getKeys parses comma-separated key:name pairs. Malformed pairs are logged
+// and skipped; if none are valid the fallback is used.
+func (s source) getKeys(key string, fallback map[string]string) map[string]string {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		apiKey, name, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || apiKey == "" || name == "" {
+			log.Printf("config: invalid %s entry %q, expected key:name", key, pair)
+			continue
+		}
+		keys[apiKey] = name
+	}
+
+	if len(keys) == 0 {
+		log.Printf("config: no valid %s entries, using defaults", key)
+		return fallback
+	}
+	return keys
+}
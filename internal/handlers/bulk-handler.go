@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"practice-one/internal/models"
+	"practice-one/internal/store"
+)
+
+const (
+	MaxBulkSize = 1000
+)
+
+// BulkCreateTasks handles POST /v1/tasks/bulk
+// @Summary Create tasks in bulk
+// @Description Create several tasks at once. By default the batch is atomic: if any item
+// @Description is invalid nothing is created. With partial=true valid items are created and
+// @Description a 207 Multi-Status lists the outcome of each item.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param tasks body []models.CreateTaskRequest true "Tasks to create"
+// @Param partial query bool false "Create valid items even if others fail"
+// @Success 201 {array} models.Task
+// @Success 207 {object} models.BulkCreateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /v1/tasks/bulk [post]
+func (h *TaskHandler) BulkCreateTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "partial") {
+		return
+	}
+
+	partial := false
+	if value := r.URL.Query().Get("partial"); value != "" {
+		var err error
+		partial, err = parseBoolParam(value, h.strictBools)
+		if err != nil {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid partial parameter"})
+			return
+		}
+	}
+
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
+
+	var reqs []models.CreateTaskRequest
+	if err := decodeJSON(r, &reqs); err != nil {
+		h.respondDecodeError(w, r, err)
+		return
+	}
+
+	if len(reqs) == 0 {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "no tasks provided"})
+		return
+	}
+
+	if len(reqs) > MaxBulkSize {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("batch exceeds maximum size of %d tasks", MaxBulkSize),
+		})
+		return
+	}
+
+	results := make([]models.BulkItemResult, len(reqs))
+	drafts := make([]models.Task, 0, len(reqs))
+	valid := make([]int, 0, len(reqs)) // indexes of items being created
+
+	for i, req := range reqs {
+		draft, err := h.newTask(r, req)
+		if err != nil {
+			if !partial {
+				h.respond(w, r, http.StatusUnprocessableEntity, models.ErrorResponse{
+					Error: fmt.Sprintf("item %d: %s", i, err.Error()),
+					Code:  "validation_failed",
+				})
+				return
+			}
+			results[i] = models.BulkItemResult{Index: i, Status: http.StatusUnprocessableEntity, Error: err.Error()}
+			continue
+		}
+
+		drafts = append(drafts, draft)
+		valid = append(valid, i)
+	}
+
+	created := h.store.CreateMany(drafts)
+
+	pres.setContentType(w)
+
+	if !partial {
+		h.respond(w, r, http.StatusCreated, h.presentTasks(created, pres))
+		return
+	}
+
+	for n, task := range created {
+		i := valid[n]
+		results[i] = models.BulkItemResult{Index: i, Status: http.StatusCreated, Task: h.presentTask(task, pres)}
+	}
+
+	h.respond(w, r, http.StatusMultiStatus, models.BulkCreateResponse{Results: results})
+}
+
+// BulkUpdateTasks handles PATCH /v1/tasks/bulk
+// @Summary Update tasks in bulk
+// @Description Update several tasks, each only if it is still at the version the client sent.
+// @Description Items are applied independently: a stale version or missing task only fails its
+// @Description own item, and a 207 Multi-Status lists the outcome of each item.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param updates body []models.BulkUpdateItem true "Updates with expected versions"
+// @Success 207 {object} models.BulkUpdateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /v1/tasks/bulk [patch]
+func (h *TaskHandler) BulkUpdateTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r) {
+		return
+	}
+
+	var items []models.BulkUpdateItem
+	if err := decodeJSON(r, &items); err != nil {
+		h.respondDecodeError(w, r, err)
+		return
+	}
+
+	if len(items) == 0 {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "no updates provided"})
+		return
+	}
+
+	if len(items) > MaxBulkSize {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("batch exceeds maximum size of %d tasks", MaxBulkSize),
+		})
+		return
+	}
+
+	results := make([]models.BulkUpdateResult, len(items))
+	updates := make([]store.VersionedUpdate, 0, len(items))
+	valid := make([]int, 0, len(items)) // indexes of items sent to the store
+
+	for i, item := range items {
+		if msg := bulkUpdateError(item); msg != "" {
+			results[i] = models.BulkUpdateResult{
+				ID:      item.ID,
+				Status:  http.StatusUnprocessableEntity,
+				Outcome: "invalid",
+				Error:   msg,
+			}
+			continue
+		}
+
+		updates = append(updates, store.VersionedUpdate{
+			ID:    item.ID,
+			Patch: store.TaskPatch{Done: item.Done, Priority: item.Priority, IfVersion: item.Version},
+		})
+		valid = append(valid, i)
+	}
+
+	for n, res := range h.store.UpdateManyOptimistic(updates) {
+		result := models.BulkUpdateResult{ID: res.ID, Outcome: string(res.Outcome), Version: res.Version}
+		switch res.Outcome {
+		case store.UpdateApplied:
+			result.Status = http.StatusOK
+		case store.UpdateConflict:
+			result.Status = http.StatusPreconditionFailed
+			result.Error = "task was modified since it was read"
+		case store.UpdateNotFound:
+			result.Status = http.StatusNotFound
+			result.Error = "task not found"
+		}
+		results[valid[n]] = result
+	}
+
+	h.respond(w, r, http.StatusMultiStatus, models.BulkUpdateResponse{Results: results})
+}
+
+// bulkUpdateError returns why item can't be applied, or "" if it is valid.
+func bulkUpdateError(item models.BulkUpdateItem) string {
+	switch {
+	case item.ID < 1:
+		return "id must be a positive integer"
+	case item.Version < 1:
+		return "version is required"
+	case item.Done == nil && item.Priority == nil:
+		return "nothing to update: set done or priority"
+	case item.Priority != nil && !validPriority(*item.Priority):
+		return "priority must be one of low, medium or high"
+	}
+	return ""
+}
+
+// BulkDeleteTasks handles DELETE /v1/tasks/bulk
+// @Summary Delete tasks in bulk
+// @Description Delete every task in ids. Missing ids are reported, not treated as errors.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param ids body models.BulkDeleteRequest true "IDs of the tasks to delete"
+// @Success 200 {object} models.BulkDeleteResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /v1/tasks/bulk [delete]
+func (h *TaskHandler) BulkDeleteTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r) {
+		return
+	}
+
+	var req models.BulkDeleteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.respondDecodeError(w, r, err)
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "ids must not be empty"})
+		return
+	}
+
+	if len(req.IDs) > MaxBulkSize {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("batch exceeds maximum size of %d tasks", MaxBulkSize),
+		})
+		return
+	}
+
+	deleted, notFound := h.store.DeleteMany(req.IDs)
+	h.respond(w, r, http.StatusOK, models.BulkDeleteResponse{Deleted: deleted, NotFound: notFound})
+}
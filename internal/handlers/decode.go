@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"practice-one/internal/models"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// errBodyTooLarge means the request body exceeded the route's size limit.
+var errBodyTooLarge = errors.New("request body too large")
+
+// unknownFieldPrefix starts the error encoding/json returns for a field the
+// target doesn't have; the package has no error type for it.
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeJSON decodes the request body into v. A leading UTF-8 BOM is
+// skipped. The returned error's message is safe to show to the client and
+// points at the location of syntax errors such as trailing commas.
+func decodeJSON(r *http.Request, v interface{}) error {
+	return decodeBody(r, v, false)
+}
+
+// decodeStrictJSON is decodeJSON, but fields v doesn't have are rejected
+// instead of ignored, so a typo such as "titel" is reported.
+func decodeStrictJSON(r *http.Request, v interface{}) error {
+	return decodeBody(r, v, true)
+}
+
+func decodeBody(r *http.Request, v interface{}, strict bool) error {
+	data, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, tooLarge.Limit)
+	} else if err != nil {
+		return errors.New("invalid request body")
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	err = json.Unmarshal(data, v)
+	if err == nil && strict {
+		// Unmarshal has already reported any syntax error; the decoder
+		// only adds the unknown-field check.
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(v)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var fieldErr *models.FieldError
+
+	switch {
+	case errors.As(err, &fieldErr):
+		return fieldErr
+	case errors.As(err, &syntaxErr):
+		offset := int(syntaxErr.Offset) - 1
+		if comma, ok := trailingComma(data, offset); ok {
+			line, col := position(data, comma)
+			return fmt.Errorf("invalid request body: trailing comma at line %d, column %d", line, col)
+		}
+		line, col := position(data, offset)
+		return fmt.Errorf("invalid request body: syntax error at line %d, column %d", line, col)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Errorf("invalid request body: %s must be %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		return fmt.Errorf("invalid request body: unknown field %s", strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+	}
+
+	return errors.New("invalid request body")
+}
+
+// respondDecodeError answers 413 when decodeJSON failed because the body was
+// too large, and 400 otherwise.
+func (h *TaskHandler) respondDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		h.respond(w, r, http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+}
+
+// skipBOM returns a reader over body without a leading UTF-8 BOM, for
+// handlers that stream the body instead of reading it whole.
+func skipBOM(body io.Reader) io.Reader {
+	br := bufio.NewReader(body)
+	if prefix, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
+// trailingComma reports whether the syntax error at offset is a closing
+// bracket preceded by a comma, returning the comma's offset.
+func trailingComma(data []byte, offset int) (int, bool) {
+	if offset < 0 || offset >= len(data) || (data[offset] != '}' && data[offset] != ']') {
+		return 0, false
+	}
+
+	for i := offset - 1; i >= 0; i-- {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case ',':
+			return i, true
+		}
+		break
+	}
+
+	return 0, false
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(data []byte, offset int) (line, col int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	line = 1 + bytes.Count(data[:offset], []byte("\n"))
+	col = offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, col
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"practice-one/internal/clock"
+	"practice-one/internal/models"
+	"practice-one/internal/store"
+)
+
+// createDeduper remembers recent creates by a hash of the caller and the
+// normalized request, so a client blindly retrying a POST gets the task
+// created by its first attempt instead of a duplicate.
+type createDeduper struct {
+	mu     sync.Mutex
+	window time.Duration
+	clock  clock.Clock
+	recent map[[sha256.Size]byte]recentCreate
+}
+
+type recentCreate struct {
+	taskID  int
+	expires time.Time
+}
+
+// WithCreateDedupWindow makes POST /v1/tasks return the existing task when
+// the same API key sends an identical request within window. This also
+// swallows deliberate identical creates inside the window, so it is off
+// unless window is positive.
+func WithCreateDedupWindow(window time.Duration) Option {
+	return func(h *TaskHandler) {
+		if window > 0 {
+			h.dedupe = &createDeduper{
+				window: window,
+				recent: make(map[[sha256.Size]byte]recentCreate),
+			}
+		}
+	}
+}
+
+// create stores draft unless an identical request from the same caller was
+// served within the window. It reports whether the returned task is a replay
+// of that earlier create.
+func (d *createDeduper) create(s store.Store, r *http.Request, draft models.Task) (*models.Task, bool) {
+	var due string
+	if draft.DueDate != nil {
+		due = draft.DueDate.Format(time.RFC3339Nano)
+	}
+	sum := sha256.Sum256([]byte(r.Header.Get("X-API-KEY") + "\x00" + draft.Title + "\x00" + draft.Priority +
+		"\x00" + strings.Join(draft.Tags, ",") + "\x00" + draft.Description + "\x00" + due))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock.Now()
+	for key, rc := range d.recent {
+		if !now.Before(rc.expires) {
+			delete(d.recent, key)
+		}
+	}
+
+	if rc, ok := d.recent[sum]; ok {
+		if task, err := s.GetByID(rc.taskID); err == nil {
+			return task, true
+		}
+	}
+
+	task := s.CreateTask(draft)
+	d.recent[sum] = recentCreate{taskID: task.ID, expires: now.Add(d.window)}
+	return task, false
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"practice-one/internal/router"
+)
+
+var errUnsupportedEncoding = errors.New("unsupported Content-Encoding: use gzip or identity")
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		}
+		return q > 0
+	}
+	return false
+}
+
+// gzipBytes compresses data.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodedBody returns the request body with any gzip Content-Encoding
+// removed. Other codings yield errUnsupportedEncoding. The route's body limit
+// applies to the decompressed stream as well as the compressed one, so a
+// small gzip bomb fails with *http.MaxBytesError instead of exhausting memory.
+func decodedBody(w http.ResponseWriter, r *http.Request) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, errors.New("invalid gzip request body")
+		}
+		if limit := router.BodyLimit(r); limit > 0 {
+			return http.MaxBytesReader(w, zr, limit), nil
+		}
+		return zr, nil
+	}
+	return nil, errUnsupportedEncoding
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"practice-one/internal/models"
+)
+
+// listETag builds a weak ETag for a list response from the store revision
+// and the request inputs (query, negotiated media type) that shaped it.
+func listETag(revision uint64, inputs ...string) string {
+	h := fnv.New32a()
+	for _, input := range inputs {
+		h.Write([]byte(input))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf(`W/"%d-%x"`, revision, h.Sum32())
+}
+
+// taskETag is the entity tag of a single task: its version, quoted, so a
+// client can send it straight back in If-Match. Every change to a task bumps
+// its version. A negotiated vendor media type is appended, as in "3-v2", so
+// caches never answer one representation with another's 304.
+func taskETag(task *models.Task, p presentation) string {
+	if p.mediaType == "" {
+		return fmt.Sprintf(`"%d"`, task.Version)
+	}
+	return fmt.Sprintf(`"%d-v%d"`, task.Version, p.version)
+}
+
+// parseIfMatch reads the task version from an If-Match header. Versions are
+// sent as quoted entity tags such as "3", or "3-v2" as served with a vendor
+// media type; a bare number is accepted too. An empty header or "*" means the
+// update is unconditional.
+func parseIfMatch(value string) (version int, conditional bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return 0, false, nil
+	}
+
+	number, _, _ := strings.Cut(strings.Trim(value, `"`), "-")
+	version, err = strconv.Atoi(number)
+	if err != nil || version < 1 {
+		return 0, false, errors.New("invalid If-Match header: expected a task version such as \"3\"")
+	}
+	return version, true, nil
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag
+// using the weak comparison from RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
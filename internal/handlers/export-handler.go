@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// ExportTasks handles GET /v1/tasks/export?format=csv
+// @Summary Export all tasks
+// @Description Export all tasks as JSON or CSV. The export is buffered so clients can
+// @Description resume interrupted downloads with a Range header (206 Partial Content).
+// @Description The format comes from ?format=, else from the Accept header, else JSON.
+// @Description With Accept-Encoding: gzip the export is gzip-compressed.
+// @Tags tasks
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "Export format: json (default) or csv"
+// @Param Accept header string false "application/json or text/csv"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {array} models.Task
+// @Success 206 {string} string "Requested byte range of the export"
+// @Failure 406 {object} models.ErrorResponse
+// @Failure 416 {string} string "Range not satisfiable"
+// @Router /v1/tasks/export [get]
+func (h *TaskHandler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "format") {
+		return
+	}
+
+	format, err := negotiateExportFormat(r)
+	if err != nil {
+		h.respond(w, r, http.StatusNotAcceptable, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tasks := h.store.GetAll()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	var buf bytes.Buffer
+	var contentType string
+
+	switch format {
+	case "json":
+		if err := json.NewEncoder(&buf).Encode(tasks); err != nil {
+			h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "failed to export tasks"})
+			return
+		}
+		contentType = "application/json"
+	case "csv":
+		if err := writeTasksCSV(&buf, tasks); err != nil {
+			h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "failed to export tasks"})
+			return
+		}
+		contentType = "text/csv; charset=utf-8"
+	}
+
+	content := buf.Bytes()
+
+	// Ranges then apply to the compressed bytes, which is what a client
+	// resuming a compressed download has partially received.
+	w.Header().Add("Vary", "Accept-Encoding")
+	if acceptsGzip(r) {
+		compressed, err := gzipBytes(content)
+		if err != nil {
+			h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "failed to export tasks"})
+			return
+		}
+		content = compressed
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	// The ETag lets clients resume with If-Range and get the full export
+	// again if the tasks changed in between.
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(content)))
+
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+// negotiateExportFormat picks "json" or "csv". An explicit ?format= wins;
+// otherwise the first supported media type in the Accept header is used,
+// defaulting to JSON when the header is absent or accepts anything.
+func negotiateExportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if format != "json" && format != "csv" {
+			return "", fmt.Errorf("unsupported format %q: use json or csv", format)
+		}
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return "json", nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json", "application/*", "*/*":
+			return "json", nil
+		case "text/csv", "text/*":
+			return "csv", nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported Accept %q: use application/json or text/csv", accept)
+}
+
+func writeTasksCSV(buf *bytes.Buffer, tasks []*models.Task) error {
+	cw := csv.NewWriter(buf)
+
+	if err := cw.Write([]string{"id", "title", "done", "created_at", "updated_at", "priority", "due_date", "tags", "description", "created_by"}); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		dueDate := ""
+		if task.DueDate != nil {
+			dueDate = task.DueDate.Format(time.RFC3339)
+		}
+
+		record := []string{
+			strconv.Itoa(task.ID),
+			task.Title,
+			strconv.FormatBool(task.Done),
+			task.CreatedAt.Format(time.RFC3339),
+			task.UpdatedAt.Format(time.RFC3339),
+			task.Priority,
+			dueDate,
+			strings.Join(task.Tags, ";"),
+			task.Description,
+			task.CreatedBy,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
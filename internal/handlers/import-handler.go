@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// importWriteGrace is how long after the import deadline the response may
+// still be written, so the client gets the final error and summary lines.
+const importWriteGrace = 5 * time.Second
+
+// ImportTasks handles POST /v1/tasks/import
+// @Summary Import tasks
+// @Description Create tasks from a JSON array, streaming one NDJSON result per item
+// @Description as it is processed so clients can follow progress on large batches.
+// @Description The last line summarizes created, skipped and failed counts. With
+// @Description dedupe=true, items whose normalized title already exists (in the store
+// @Description or earlier in the batch) are skipped. A gzip-compressed body is accepted
+// @Description with Content-Encoding: gzip. Each item is read as a create request, so
+// @Description importing an export is not a lossless restore: id, done, version, createdAt,
+// @Description updatedAt and createdBy are dropped, tasks get new ids, and items whose
+// @Description dueDate has passed are rejected as they would be by POST /v1/tasks.
+// @Description The import runs under its own deadline; if it passes, an error line marks
+// @Description the first unprocessed item before the summary.
+// @Tags tasks
+// @Accept json
+// @Produce application/x-ndjson
+// @Param tasks body []models.CreateTaskRequest true "Tasks to import"
+// @Param dedupe query bool false "Skip items with duplicate titles"
+// @Param Content-Encoding header string false "gzip for a compressed body"
+// @Success 200 {object} models.ImportResult "One line per item, then a models.ImportSummary"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Router /v1/tasks/import [post]
+func (h *TaskHandler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "dedupe") {
+		return
+	}
+
+	dedupe := false
+	if value := r.URL.Query().Get("dedupe"); value != "" {
+		var err error
+		dedupe, err = parseBoolParam(value, h.strictBools)
+		if err != nil {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid dedupe parameter"})
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+
+	// The import replaces the server's read and write timeouts with its own
+	// deadline, leaving a little longer for writing the final lines once it
+	// passes; zero deadlines lift them.
+	ctx := r.Context()
+	var readDeadline, writeDeadline time.Time
+	if h.importTimeout > 0 {
+		readDeadline = time.Now().Add(h.importTimeout)
+		writeDeadline = readDeadline.Add(importWriteGrace)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, readDeadline)
+		defer cancel()
+	}
+	rc.SetReadDeadline(readDeadline)
+	rc.SetWriteDeadline(writeDeadline)
+
+	// Results are written while the body is still being read, which HTTP/1
+	// only allows in full-duplex mode.
+	rc.EnableFullDuplex()
+
+	body, err := decodedBody(w, r)
+	if errors.Is(err, errUnsupportedEncoding) {
+		h.respond(w, r, http.StatusUnsupportedMediaType, models.ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	dec := json.NewDecoder(skipBOM(body))
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "request body must be a JSON array"})
+		return
+	}
+
+	var seen map[string]bool
+	if dedupe {
+		seen = h.titleIndex()
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+
+	var counts models.ImportCounts
+	defer func() {
+		enc.Encode(models.ImportSummary{Summary: counts})
+		rc.Flush()
+	}()
+
+	for index := 0; dec.More(); index++ {
+		result := models.ImportResult{Index: index}
+
+		if ctx.Err() != nil {
+			result.Error = importStopped(ctx)
+			counts.Failed++
+			enc.Encode(result)
+			return
+		}
+
+		// Items are split off as raw JSON first: only a failure to read the
+		// stream ends the import, while an item that doesn't fit
+		// CreateTaskRequest just fails on its own.
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+
+		var req models.CreateTaskRequest
+
+		var syntaxErr *json.SyntaxError
+		var tooLarge *http.MaxBytesError
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// Reading the body failed because the deadline passed.
+			result.Error = importStopped(ctx)
+			counts.Failed++
+			enc.Encode(result)
+			return
+		case errors.As(err, &syntaxErr):
+			// The rest of the stream can't be parsed reliably.
+			result.Error = "invalid JSON: " + err.Error()
+			counts.Failed++
+			enc.Encode(result)
+			return
+		case errors.As(err, &tooLarge):
+			result.Error = fmt.Sprintf("request body too large: limit is %d bytes", tooLarge.Limit)
+			counts.Failed++
+			enc.Encode(result)
+			return
+		case err != nil:
+			result.Error = "reading request body: " + err.Error()
+			counts.Failed++
+			enc.Encode(result)
+			return
+		case json.Unmarshal(raw, &req) != nil:
+			result.Error = "invalid item"
+		default:
+			draft, err := h.newTask(r, req)
+			switch {
+			case err != nil:
+				result.Error = err.Error()
+			case dedupe && seen[dedupeKey(draft.Title)]:
+				result.Skipped = true
+			default:
+				result.ID = h.store.CreateTask(draft).ID
+				if dedupe {
+					seen[dedupeKey(draft.Title)] = true
+				}
+			}
+		}
+
+		switch {
+		case result.Error != "":
+			counts.Failed++
+		case result.Skipped:
+			counts.Skipped++
+		default:
+			counts.Created++
+		}
+
+		enc.Encode(result)
+		rc.Flush()
+	}
+}
+
+// importStopped describes why an import ended before the end of its body.
+func importStopped(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "import timed out; this and later items were not processed"
+	}
+	return "import cancelled; this and later items were not processed"
+}
+
+// titleIndex returns the dedupe keys of all stored titles.
+func (h *TaskHandler) titleIndex() map[string]bool {
+	index := make(map[string]bool)
+	h.store.ForEach(func(task *models.Task) bool {
+		index[dedupeKey(task.Title)] = true
+		return true
+	})
+
+	return index
+}
+
+// dedupeKey is the normalized form used to detect duplicate titles: case and
+// whitespace differences are ignored.
+func dedupeKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
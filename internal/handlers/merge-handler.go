@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"practice-one/internal/models"
+	"practice-one/internal/store"
+)
+
+// MergeTasks handles POST /v1/tasks/merge
+// @Summary Merge two tasks
+// @Description Merge the source task into the target: the target keeps its title,
+// @Description is done if either task was done, gains the source's tags, and the source is deleted.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param merge body models.MergeTasksRequest true "Source and target task IDs"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /v1/tasks/merge [post]
+func (h *TaskHandler) MergeTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r) {
+		return
+	}
+
+	var req models.MergeTasksRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.respondDecodeError(w, r, err)
+		return
+	}
+
+	if req.Source <= 0 || req.Target <= 0 {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "source and target must be valid ids"})
+		return
+	}
+
+	if req.Source == req.Target {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "source and target must differ"})
+		return
+	}
+
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
+
+	task, err := h.store.Merge(req.Source, req.Target)
+	if errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	h.respondTask(w, r, http.StatusOK, task, pres)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"practice-one/internal/models"
+	"practice-one/internal/router"
+	"practice-one/internal/store"
+)
+
+// parseIntParam parses a base-10 query value and checks it lies within
+// [min, max]. Values too large for an int64 are reported as out of range
+// rather than as a generic parse failure.
+func parseIntParam(name, value string, min, max int) (int, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if errors.Is(err, strconv.ErrRange) || (err == nil && (n < int64(min) || n > int64(max))) {
+		return 0, fmt.Errorf("%s out of range: must be between %d and %d", name, min, max)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+
+	return int(n), nil
+}
+
+// idParam returns the raw task id from the /v1/tasks/:id path, falling back
+// to the legacy ?id= query parameter.
+func idParam(r *http.Request) string {
+	if id := router.Param(r, "id"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("id")
+}
+
+func (h *TaskHandler) parseID(value string) (int, error) {
+	max := math.MaxInt
+	if h.maxID > 0 {
+		max = h.maxID
+	}
+	return parseIntParam("id", value, 1, max)
+}
+
+// checkQueryParams reports whether every query parameter of r is in known.
+// It only rejects anything when strict query handling is enabled for the
+// handler or requested with "Prefer: handling=strict"; otherwise it writes
+// nothing and returns true. On rejection it responds with 400 naming the
+// first unknown parameter.
+func (h *TaskHandler) checkQueryParams(w http.ResponseWriter, r *http.Request, known ...string) bool {
+	if !h.strictQuery && !prefersStrict(r) {
+		return true
+	}
+
+	var unknown []string
+	for name := range r.URL.Query() {
+		if !slices.Contains(known, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return true
+	}
+
+	sort.Strings(unknown)
+	h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{
+		Error: fmt.Sprintf("unknown query parameter %q", unknown[0]),
+		Code:  "unknown_query_param",
+	})
+	return false
+}
+
+// prefersStrict reports whether the request carries the RFC 7240
+// "handling=strict" preference.
+func prefersStrict(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			pref, _, _ = strings.Cut(pref, ";")
+			name, value, _ := strings.Cut(strings.TrimSpace(pref), "=")
+			if strings.EqualFold(name, "handling") && strings.EqualFold(strings.Trim(value, `"`), "strict") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseBoolParam parses a boolean query value. Besides the forms accepted by
+// strconv.ParseBool it understands yes/no and on/off (case-insensitive),
+// unless strict is set.
+func parseBoolParam(value string, strict bool) (bool, error) {
+	if b, err := strconv.ParseBool(value); err == nil || strict {
+		return b, err
+	}
+
+	switch strings.ToLower(value) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("invalid boolean %q", value)
+}
+
+// Page size limits for the task list.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+type page struct {
+	limit  int
+	offset int
+}
+
+// parsePage reads ?limit= and ?offset=, applying the defaults when absent.
+func parsePage(r *http.Request) (page, error) {
+	pg := page{limit: DefaultPageLimit}
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := parseIntParam("limit", value, 1, MaxPageLimit)
+		if err != nil {
+			return pg, err
+		}
+		pg.limit = limit
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := parseIntParam("offset", value, 0, math.MaxInt)
+		if err != nil {
+			return pg, err
+		}
+		pg.offset = offset
+	}
+
+	return pg, nil
+}
+
+// pageLinks builds an RFC 8288 Link header value with first, prev, next and
+// last URLs for the page pg of a list holding total items. The URLs keep the
+// request's other query parameters; prev and next are left out at the ends.
+func pageLinks(r *http.Request, total int, pg page) string {
+	link := func(offset int, rel string) string {
+		query := r.URL.Query()
+		query.Set("limit", strconv.Itoa(pg.limit))
+		query.Set("offset", strconv.Itoa(offset))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / pg.limit * pg.limit
+	}
+
+	// Offsets can be anything up to math.MaxInt, so nothing here adds to
+	// pg.offset, and prev from past the end points at the last page.
+	links := []string{link(0, "first")}
+	if pg.offset > 0 {
+		links = append(links, link(min(max(pg.offset-pg.limit, 0), last), "prev"))
+	}
+	if pg.offset < total-pg.limit {
+		links = append(links, link(pg.offset+pg.limit, "next"))
+	}
+	links = append(links, link(last, "last"))
+
+	return strings.Join(links, ", ")
+}
+
+// parseFilter builds the task filter shared by the list and count endpoints.
+func (h *TaskHandler) parseFilter(r *http.Request) (store.Filter, error) {
+	var filter store.Filter
+
+	doneParam := r.URL.Query().Get("done")
+	if doneParam == "" && h.pendingByDefault {
+		doneParam = "false"
+	}
+
+	if doneParam != "" && doneParam != "all" {
+		done, err := parseBoolParam(doneParam, h.strictBools)
+		if err != nil {
+			return filter, errors.New("invalid done parameter")
+		}
+		filter.Done = &done
+	}
+
+	if priority := r.URL.Query().Get("priority"); priority != "" {
+		if !validPriority(priority) {
+			return filter, errors.New("invalid priority parameter: expected low, medium or high")
+		}
+		filter.Priority = priority
+	}
+
+	for _, tag := range r.URL.Query()["tag"] {
+		filter.Tags = append(filter.Tags, strings.ToLower(strings.TrimSpace(tag)))
+	}
+
+	if value := r.URL.Query().Get("includeDeleted"); value != "" {
+		include, err := parseBoolParam(value, h.strictBools)
+		if err != nil {
+			return filter, errors.New("invalid includeDeleted parameter")
+		}
+		filter.IncludeDeleted = include
+	}
+
+	if value := r.URL.Query().Get("overdue"); value != "" {
+		overdue, err := parseBoolParam(value, h.strictBools)
+		if err != nil {
+			return filter, errors.New("invalid overdue parameter")
+		}
+		if overdue {
+			filter.OverdueAt = h.clock.Now()
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// Vendor media types selecting the task representation. v2 renames done to
+// completed; clients that don't ask for a vendor type get v1.
+const (
+	MediaTypeTasksV1 = "application/vnd.tasks.v1+json"
+	MediaTypeTasksV2 = "application/vnd.tasks.v2+json"
+)
+
+var errNotAcceptable = errors.New("unsupported task media type version")
+
+// presentation describes how tasks are rendered for one request.
+type presentation struct {
+	computed  bool
+	version   int
+	mediaType string // empty unless a vendor type was negotiated
+}
+
+// parsePresentation reads ?expand= and negotiates the representation version
+// from the Accept header.
+func parsePresentation(r *http.Request) (presentation, error) {
+	p := presentation{version: 1}
+
+	computed, err := parseExpand(r)
+	if err != nil {
+		return p, err
+	}
+	p.computed = computed
+
+	p.version, p.mediaType, err = negotiateVersion(r.Header.Get("Accept"))
+	return p, err
+}
+
+// respondPresentationError maps a parsePresentation error to a response.
+func (h *TaskHandler) respondPresentationError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errNotAcceptable) {
+		h.respond(w, r, http.StatusNotAcceptable, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+}
+
+// negotiateVersion picks the first vendor task media type listed in accept.
+func negotiateVersion(accept string) (int, string, error) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		switch {
+		case mediaType == MediaTypeTasksV1:
+			return 1, MediaTypeTasksV1, nil
+		case mediaType == MediaTypeTasksV2:
+			return 2, MediaTypeTasksV2, nil
+		case strings.HasPrefix(mediaType, "application/vnd.tasks."):
+			return 0, "", fmt.Errorf("%w: %s", errNotAcceptable, mediaType)
+		}
+	}
+
+	return 1, "", nil
+}
+
+// parseExpand reads the optional ?expand= list. The only supported value is
+// "computed", which adds derived fields to task responses.
+func parseExpand(r *http.Request) (computed bool, err error) {
+	value := r.URL.Query().Get("expand")
+	if value == "" {
+		return false, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		switch strings.TrimSpace(part) {
+		case "computed":
+			computed = true
+		default:
+			return false, fmt.Errorf("invalid expand value %q", part)
+		}
+	}
+
+	return computed, nil
+}
+
+// respondTask writes a single task in the negotiated representation.
+func (h *TaskHandler) respondTask(w http.ResponseWriter, r *http.Request, status int, task *models.Task, p presentation) {
+	p.setContentType(w)
+	h.respond(w, r, status, h.presentTask(task, p))
+}
+
+// respondTaskPage writes one page of tasks, in the negotiated
+// representation, wrapped in a pagination envelope, with Link headers to the
+// neighboring pages.
+func (h *TaskHandler) respondTaskPage(w http.ResponseWriter, r *http.Request, status int, tasks []*models.Task, total int, pg page, p presentation) {
+	p.setContentType(w)
+	w.Header().Set("Link", pageLinks(r, total, pg))
+	h.respond(w, r, status, models.TaskPage{
+		Items:  h.presentTasks(tasks, p),
+		Total:  total,
+		Limit:  pg.limit,
+		Offset: pg.offset,
+	})
+}
+
+// presentTasks returns the value to serialize for a list of tasks.
+func (h *TaskHandler) presentTasks(tasks []*models.Task, p presentation) interface{} {
+	if !p.computed && p.version == 1 {
+		return tasks
+	}
+
+	presented := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		presented[i] = h.presentTask(task, p)
+	}
+	return presented
+}
+
+func (p presentation) setContentType(w http.ResponseWriter) {
+	w.Header().Add("Vary", "Accept")
+	if p.mediaType != "" {
+		w.Header().Set("Content-Type", p.mediaType)
+	}
+}
+
+// presentTask returns the value to serialize for task.
+func (h *TaskHandler) presentTask(task *models.Task, p presentation) interface{} {
+	var age int64
+	var overdue bool
+	if p.computed {
+		now := h.clock.Now()
+		age = int64(now.Sub(task.CreatedAt) / time.Second)
+		overdue = task.IsOverdue(now)
+	}
+
+	switch {
+	case p.version == 2:
+		v2 := taskV2(task)
+		if p.computed {
+			v2.AgeSeconds = &age
+			v2.IsOverdue = &overdue
+		}
+		return v2
+	case p.computed:
+		return models.ExpandedTask{Task: *task, AgeSeconds: age, IsOverdue: overdue}
+	default:
+		return task
+	}
+}
+
+// taskV2 converts task to the v2 representation, without computed fields.
+func taskV2(task *models.Task) models.TaskV2 {
+	return models.TaskV2{
+		ID:          task.ID,
+		Title:       task.Title,
+		Completed:   task.Done,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		CreatedBy:   task.CreatedBy,
+		Version:     task.Version,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		Tags:        task.Tags,
+		Description: task.Description,
+		DeletedAt:   task.DeletedAt,
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"practice-one/internal/models"
+	"practice-one/internal/store"
+)
+
+// RandomTask handles GET /v1/tasks/random
+// @Summary Pick a random task
+// @Description Return a randomly chosen pending task, for "pick something to do". With
+// @Description pending=false done tasks may be picked too. Deleted tasks are never picked.
+// @Tags tasks
+// @Produce json
+// @Param pending query bool false "Only pick pending tasks (default true)"
+// @Param expand query string false "Set to computed to include derived fields"
+// @Param Accept header string false "application/vnd.tasks.v2+json for the v2 representation"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/tasks/random [get]
+func (h *TaskHandler) RandomTask(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "pending", "expand") {
+		return
+	}
+
+	pending := true
+	if value := r.URL.Query().Get("pending"); value != "" {
+		var err error
+		pending, err = parseBoolParam(value, h.strictBools)
+		if err != nil {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid pending parameter"})
+			return
+		}
+	}
+
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
+
+	task, err := h.store.Random(pending)
+	if errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: "no task to pick"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	h.respondTask(w, r, http.StatusOK, task, pres)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"practice-one/internal/models"
+)
+
+// SearchTasks handles GET /v1/tasks/search?q=groceries
+// @Summary Search tasks
+// @Description Search task titles case-insensitively. Results are ranked: exact title
+// @Description matches (score 3) first, then prefix matches (2), then substring matches (1).
+// @Tags tasks
+// @Produce json
+// @Param q query string true "Search text"
+// @Success 200 {array} models.ScoredTask
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse
+// @Router /v1/tasks/search [get]
+func (h *TaskHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "q") {
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "q parameter is required"})
+		return
+	}
+
+	// Search hits have no computed fields, so only the version is negotiated.
+	var pres presentation
+	var err error
+	pres.version, pres.mediaType, err = negotiateVersion(r.Header.Get("Accept"))
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
+	pres.setContentType(w)
+
+	hits := h.store.Search(q)
+	if pres.version == 1 {
+		h.respond(w, r, http.StatusOK, hits)
+		return
+	}
+
+	presented := make([]models.ScoredTaskV2, len(hits))
+	for i, hit := range hits {
+		presented[i] = models.ScoredTaskV2{TaskV2: taskV2(&hit.Task), Score: hit.Score}
+	}
+	h.respond(w, r, http.StatusOK, presented)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Serializer writes response bodies in one media type.
+type Serializer interface {
+	Serialize(w io.Writer, data interface{}) error
+	ContentType() string
+}
+
+// JSONSerializer is the default serializer.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(w io.Writer, data interface{}) error {
+	return json.NewEncoder(w).Encode(data)
+}
+
+func (JSONSerializer) ContentType() string {
+	return "application/json"
+}
+
+// WithSerializer registers s for requests whose Accept header names its
+// content type. JSON is always available and used when nothing else matches.
+func WithSerializer(s Serializer) Option {
+	return func(h *TaskHandler) {
+		h.serializers[mediaType(s.ContentType())] = s
+	}
+}
+
+// WithDefaultContentType sets the media type served to clients that send no
+// Accept header (or only */*). It needs a serializer registered with
+// WithSerializer; otherwise JSON is used.
+func WithDefaultContentType(contentType string) Option {
+	return func(h *TaskHandler) {
+		h.defaultMediaType = mediaType(contentType)
+	}
+}
+
+// HasSerializer reports whether responses can be written as contentType.
+func (h *TaskHandler) HasSerializer(contentType string) bool {
+	_, ok := h.serializers[mediaType(contentType)]
+	return ok
+}
+
+// serializerFor picks the serializer for the first media type in the Accept
+// header that has one. The task vendor types are JSON; anything unknown
+// falls back to JSON rather than failing the request. Without a preference
+// the configured default is used.
+func (h *TaskHandler) serializerFor(r *http.Request) Serializer {
+	accept := mediaType(r.Header.Get("Accept"))
+	if accept == "" || accept == "*/*" {
+		if s, ok := h.serializers[h.defaultMediaType]; ok {
+			return s
+		}
+		return h.serializers["application/json"]
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt := mediaType(part)
+		if s, ok := h.serializers[mt]; ok {
+			return s
+		}
+		if strings.HasPrefix(mt, "application/vnd.tasks.") {
+			break
+		}
+	}
+	return h.serializers["application/json"]
+}
+
+func mediaType(value string) string {
+	mt, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+// respond writes data with the serializer negotiated for r. A Content-Type
+// already set by the caller (e.g. a negotiated vendor media type) is kept.
+func (h *TaskHandler) respond(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	s := h.serializerFor(r)
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", s.ContentType())
+	}
+	w.WriteHeader(status)
+	s.Serialize(w, data)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"practice-one/internal/models"
+	"practice-one/internal/store"
+)
+
+// CountTasks handles GET /v1/tasks/count?done=false
+// @Summary Count tasks
+// @Description Count tasks matching the same filters as the list endpoint
+// @Tags tasks
+// @Produce json
+// @Param done query string false "Filter by done status, or all"
+// @Param priority query string false "Filter by priority: low, medium or high"
+// @Param overdue query bool false "Only incomplete tasks whose due date has passed"
+// @Param tag query []string false "Only tasks with this tag; repeat to require several"
+// @Param includeDeleted query bool false "Also count soft-deleted tasks"
+// @Success 200 {object} models.CountResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /v1/tasks/count [get]
+func (h *TaskHandler) CountTasks(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "done", "priority", "overdue", "tag", "includeDeleted") {
+		return
+	}
+
+	filter, err := h.parseFilter(r)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, models.CountResponse{Count: h.store.Count(filter)})
+}
+
+// GetGroupedStats handles GET /v1/tasks/stats/grouped?by=done
+// @Summary Count tasks per group
+// @Description Count tasks grouped by the chosen dimension
+// @Tags tasks
+// @Produce json
+// @Param by query string true "Grouping dimension (done, priority or tag)"
+// @Success 200 {object} models.GroupedStatsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /v1/tasks/stats/grouped [get]
+func (h *TaskHandler) GetGroupedStats(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "by") {
+		return
+	}
+
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "by parameter is required"})
+		return
+	}
+
+	counts, err := h.store.CountBy(by)
+	if errors.Is(err, store.ErrInvalidGroup) {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid by parameter"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, models.GroupedStatsResponse{By: by, Counts: counts})
+}
+
+// Snapshot handles GET /v1/_admin/snapshot
+// @Summary Snapshot store state
+// @Description Returns a deterministic hash of all tasks so callers can cheaply check
+// @Description whether anything changed between two points in time.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.SnapshotResponse
+// @Router /v1/_admin/snapshot [get]
+func (h *TaskHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r) {
+		return
+	}
+
+	// Read the revision first so it is never newer than the hashed state.
+	revision := h.store.Revision()
+
+	h.respond(w, r, http.StatusOK, models.SnapshotResponse{
+		Hash:     store.StateHash(h.store),
+		Revision: revision,
+		Count:    h.store.Count(store.Filter{}),
+	})
+}
@@ -1,198 +1,764 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
+	"practice-one/internal/clock"
+	"practice-one/internal/middleware"
 	"practice-one/internal/models"
 	"practice-one/internal/store"
 )
 
 const (
-	MaxTitleLength = 200
+	MaxTitleLength       = 200
+	MaxTags              = 20
+	MaxTagLength         = 50
+	MaxDescriptionLength = 2000
 )
 
 type TaskHandler struct {
-	store *store.TaskStore
+	store  store.Store
+	titles TitleNormalizer
+	clock  clock.Clock
+
+	// strictBools limits boolean query params to the strconv.ParseBool forms.
+	strictBools bool
+	// pendingByDefault makes the list endpoint show only pending tasks
+	// unless a done filter is given.
+	pendingByDefault bool
+	// maxID is the largest id accepted in requests; zero means no limit.
+	maxID int
+	// strictQuery rejects query parameters an endpoint does not recognize.
+	strictQuery bool
+	// serializers maps media types to response serializers.
+	serializers map[string]Serializer
+	// defaultMediaType selects the serializer for requests that express no
+	// preference; it falls back to JSON if nothing is registered for it.
+	defaultMediaType string
+	// dedupe suppresses repeated identical creates; nil when disabled.
+	dedupe *createDeduper
+	// importTimeout bounds POST /v1/tasks/import; zero means no deadline.
+	importTimeout time.Duration
+}
+
+// Option configures optional TaskHandler behavior.
+type Option func(*TaskHandler)
+
+// WithTitleNormalizer sets the normalization applied to incoming titles.
+func WithTitleNormalizer(n TitleNormalizer) Option {
+	return func(h *TaskHandler) {
+		h.titles = n
+	}
+}
+
+// WithClock sets the clock used for computed fields such as task age.
+func WithClock(c clock.Clock) Option {
+	return func(h *TaskHandler) {
+		h.clock = c
+	}
+}
+
+// WithStrictBoolParams disables the yes/no and on/off aliases for boolean
+// query parameters.
+func WithStrictBoolParams(strict bool) Option {
+	return func(h *TaskHandler) {
+		h.strictBools = strict
+	}
+}
+
+// WithPendingByDefault makes GET /v1/tasks without a done filter list only
+// pending tasks. Clients can still request everything with done=all.
+func WithPendingByDefault(enabled bool) Option {
+	return func(h *TaskHandler) {
+		h.pendingByDefault = enabled
+	}
 }
 
-func NewTaskHandler(store *store.TaskStore) *TaskHandler {
-	return &TaskHandler{store: store}
+// WithMaxID rejects ids above max with 400 instead of looking them up.
+func WithMaxID(max int) Option {
+	return func(h *TaskHandler) {
+		h.maxID = max
+	}
+}
+
+// WithStrictQueryParams rejects requests carrying query parameters the
+// endpoint does not know, e.g. a mistyped ?dne=true, instead of ignoring them.
+func WithStrictQueryParams(strict bool) Option {
+	return func(h *TaskHandler) {
+		h.strictQuery = strict
+	}
+}
+
+// WithImportTimeout sets the deadline for an import. It replaces the
+// server's read and write timeouts for that request, so it should be
+// exempted from the Timeout middleware.
+func WithImportTimeout(d time.Duration) Option {
+	return func(h *TaskHandler) {
+		h.importTimeout = d
+	}
+}
+
+// NewTaskHandler panics if store is nil so that a misconfigured server fails
+// at startup rather than on its first request.
+func NewTaskHandler(store store.Store, opts ...Option) *TaskHandler {
+	if isNil(store) {
+		panic("handlers: NewTaskHandler requires a non-nil store")
+	}
+
+	h := &TaskHandler{
+		store: store,
+		clock: clock.Real{},
+		serializers: map[string]Serializer{
+			"application/json": JSONSerializer{},
+		},
+		defaultMediaType: "application/json",
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.dedupe != nil {
+		h.dedupe.clock = h.clock
+	}
+	return h
 }
 
-// GetTask handles GET /v1/tasks?id=X
+// GetTask handles GET /v1/tasks/{id} and GET /v1/tasks?id=X
 // @Summary Get a single task
-// @Description Get task by ID
+// @Description Get task by ID. The ETag is the task version, e.g. "3", or "3-v2" for a
+// @Description vendor media type; send it in If-None-Match to get 304 Not Modified while
+// @Description the task is unchanged, or in If-Match on an update. Responses with
+// @Description expand=computed have no ETag.
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param id query int true "Task ID"
+// @Param id path int true "Task ID"
+// @Param expand query string false "Set to computed to include derived fields"
+// @Param Accept header string false "application/vnd.tasks.v2+json for the v2 representation"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} models.Task
+// @Success 304 "Not modified"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /v1/tasks [get]
+// @Router /v1/tasks/{id} [get]
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
+	if !h.checkQueryParams(w, r, "id", "done", "priority", "overdue", "tag", "includeDeleted", "format", "group", "expand", "limit", "offset", "sort", "order", "modifiedSince") {
+		return
+	}
+
+	idStr := idParam(r)
 	if idStr == "" {
 		// If no ID provided, return all tasks
 		h.GetAllTasks(w, r)
 		return
 	}
 
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid id"})
+	id, err := h.parseID(idStr)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
 		return
 	}
 
 	task, err := h.store.GetByID(id)
-	if err == store.ErrTaskNotFound {
-		respondJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+	if errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
 		return
 	}
 
-	respondJSON(w, http.StatusOK, task)
+	// Computed fields such as overdue change as time passes without the
+	// version changing, so those responses get no ETag.
+	if !pres.computed {
+		etag := taskETag(task, pres)
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	h.respondTask(w, r, http.StatusOK, task, pres)
 }
 
 // GetAllTasks handles GET /v1/tasks or GET /v1/tasks?done=true
 // @Summary Get all tasks
-// @Description Get all tasks, optionally filtered by done status
+// @Description Get all tasks, optionally filtered by done status. When the server runs
+// @Description with pending-by-default, omitting done lists only pending tasks; done=all lists everything.
+// @Description Results are ordered by sort (default id) and paginated with limit and offset.
+// @Description With modifiedSince, returns a delta instead: every task updated after the
+// @Description timestamp plus the ids of tasks deleted after it. With group, returns every
+// @Description matching task split into sections by status, priority or tag.
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param done query bool false "Filter by done status"
-// @Success 200 {array} models.Task
+// @Param done query string false "Filter by done status, or all"
+// @Param priority query string false "Filter by priority: low, medium or high"
+// @Param overdue query bool false "Only incomplete tasks whose due date has passed"
+// @Param tag query []string false "Only tasks with this tag; repeat to require several"
+// @Param includeDeleted query bool false "Also list soft-deleted tasks"
+// @Param format query string false "list (default) for a page, or map for an object of all matching tasks keyed by id"
+// @Param group query string false "status, priority or tag: an object of all matching tasks in sections, e.g. {\"pending\": [...], \"done\": [...]}"
+// @Param sort query string false "Sort field: id (default), title or created"
+// @Param order query string false "Sort order: asc (default) or desc"
+// @Param limit query int false "Page size, 1-100 (default 20)"
+// @Param offset query int false "Number of tasks to skip (default 0)"
+// @Param modifiedSince query string false "RFC 3339 timestamp for delta sync"
+// @Param expand query string false "Set to computed to include derived fields"
+// @Param Accept header string false "application/vnd.tasks.v2+json for the v2 representation"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.TaskPage
+// @Success 200 {object} map[string]models.Task
+// @Success 200 {object} map[string][]models.Task
+// @Success 200 {object} models.TaskDelta
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.ErrorResponse
 // @Router /v1/tasks [get]
 func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	doneParam := r.URL.Query().Get("done")
-
-	var tasks []*models.Task
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
 
-	if doneParam != "" {
-		done, err := strconv.ParseBool(doneParam)
+	if value := r.URL.Query().Get("modifiedSince"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
 		if err != nil {
-			respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid done parameter"})
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid modifiedSince: expected an RFC 3339 timestamp"})
+			return
+		}
+
+		tasks, deleted := h.store.GetModifiedSince(since)
+		pres.setContentType(w)
+		h.respond(w, r, http.StatusOK, models.TaskDelta{Items: h.presentTasks(tasks, pres), Deleted: deleted})
+		return
+	}
+
+	filter, err := h.parseFilter(r)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pg, err := parsePage(r)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// format=map returns every matching task keyed by id, so paging and
+	// ordering don't apply.
+	asMap := false
+	switch r.URL.Query().Get("format") {
+	case "", "list":
+	case "map":
+		asMap = true
+		q := r.URL.Query()
+		if q.Has("limit") || q.Has("offset") || q.Has("sort") || q.Has("order") {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "limit, offset, sort and order do not apply to format=map"})
+			return
+		}
+	default:
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid format: use list or map"})
+		return
+	}
+
+	// group splits every matching task into sections, so paging and
+	// ordering don't apply either.
+	group := r.URL.Query().Get("group")
+	switch group {
+	case "":
+	case "status", "priority", "tag":
+		q := r.URL.Query()
+		if asMap {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "group does not apply to format=map"})
 			return
 		}
-		tasks = h.store.GetByStatus(done)
-	} else {
-		tasks = h.store.GetAll()
+		if q.Has("limit") || q.Has("offset") || q.Has("sort") || q.Has("order") {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "limit, offset, sort and order do not apply to group"})
+			return
+		}
+	default:
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid group: use status, priority or tag"})
+		return
+	}
+
+	srt, err := store.NewSort(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid sort: use sort=id|title|created and order=asc|desc"})
+		return
+	}
+
+	// The revision is read before the tasks, so a concurrent change can only
+	// make the ETag older than the body, never produce a false 304. Overdue
+	// lists and computed fields such as age_seconds change as time passes
+	// without any write, so those responses get no ETag.
+	if filter.OverdueAt.IsZero() && !pres.computed {
+		etag := listETag(h.store.Revision(), r.URL.RawQuery, pres.mediaType)
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if group != "" {
+		groups, err := h.store.GroupBy(group, filter)
+		if errors.Is(err, store.ErrInvalidGroup) {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid group: use status, priority or tag"})
+			return
+		} else if err != nil {
+			h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+			return
+		}
+
+		presented := make(map[string]interface{}, len(groups))
+		for key, tasks := range groups {
+			presented[key] = h.presentTasks(tasks, pres)
+		}
+		pres.setContentType(w)
+		h.respond(w, r, http.StatusOK, presented)
+		return
+	}
+
+	if asMap {
+		tasks := h.store.FindMap(filter)
+		presented := make(map[int]interface{}, len(tasks))
+		for id, task := range tasks {
+			presented[id] = h.presentTask(task, pres)
+		}
+		pres.setContentType(w)
+		h.respond(w, r, http.StatusOK, presented)
+		return
 	}
 
-	respondJSON(w, http.StatusOK, tasks)
+	tasks, total := h.store.GetPaged(filter, srt, pg.limit, pg.offset)
+
+	h.respondTaskPage(w, r, http.StatusOK, tasks, total, pg, pres)
 }
 
 // CreateTask handles POST /v1/tasks
 // @Summary Create a new task
-// @Description Create a new task with title
+// @Description Create a new task with title. When the server runs with a create dedup window,
+// @Description an identical request from the same API key within the window returns the
+// @Description task from the first request with Idempotent-Replayed: true. Unknown fields in
+// @Description the body are rejected with 400.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param task body models.CreateTaskRequest true "Task to create"
+// @Param expand query string false "Set to computed to include derived fields"
+// @Param Accept header string false "application/vnd.tasks.v2+json for the v2 representation"
 // @Success 201 {object} models.Task
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
 // @Router /v1/tasks [post]
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "expand") {
+		return
+	}
+
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
+
 	var req models.CreateTaskRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body"})
+	if err := decodeStrictJSON(r, &req); err != nil {
+		h.respondDecodeError(w, r, err)
 		return
 	}
 
-	req.Title = strings.TrimSpace(req.Title)
-	if req.Title == "" {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid title"})
+	draft, err := h.newTask(r, req)
+	if err != nil {
+		h.respondValidationError(w, r, err)
 		return
 	}
 
-	if len(req.Title) > MaxTitleLength {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{
-			Error: fmt.Sprintf("title exceeds maximum length of %d characters", MaxTitleLength),
-		})
+	if h.dedupe != nil {
+		task, replayed := h.dedupe.create(h.store, r, draft)
+		if replayed {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+		h.respondTask(w, r, http.StatusCreated, task, pres)
 		return
 	}
 
-	task := h.store.Create(req.Title)
-	respondJSON(w, http.StatusCreated, task)
+	task := h.store.CreateTask(draft)
+	h.respondTask(w, r, http.StatusCreated, task, pres)
 }
 
-// UpdateTask handles PATCH /v1/tasks?id=X
+// UpdateTask handles PATCH /v1/tasks/{id} and PATCH /v1/tasks?id=X
 // @Summary Update a task
-// @Description Update task's done status
+// @Description Update a task's title, done status, priority and/or description; omitted fields are left unchanged. With If-Match set to the version from a previous
+// @Description read, the update only applies if nobody changed the task in between.
+// @Description With upsert=true an unconditional PATCH of a missing id creates the task under
+// @Description that id from the body (a title is then required) and answers 201. Unknown
+// @Description fields in the body are rejected with 400.
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param id query int true "Task ID"
+// @Param id path int true "Task ID"
+// @Param If-Match header string false "Expected task version, e.g. \"3\""
+// @Param upsert query bool false "Create the task if the id doesn't exist"
 // @Param task body models.UpdateTaskRequest true "Update data"
 // @Success 200 {object} models.SuccessResponse
+// @Success 201 {object} models.Task
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /v1/tasks [patch]
+// @Failure 406 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /v1/tasks/{id} [patch]
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
+	if !h.checkQueryParams(w, r, "id", "upsert") {
+		return
+	}
+
+	idStr := idParam(r)
 	if idStr == "" {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "id parameter is required"})
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "id parameter is required"})
+		return
+	}
+
+	id, err := h.parseID(idStr)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid id"})
+	version, conditional, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	upsert := false
+	if value := r.URL.Query().Get("upsert"); value != "" {
+		upsert, err = parseBoolParam(value, h.strictBools)
+		if err != nil {
+			h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid upsert parameter"})
+			return
+		}
+	}
+
 	var req models.UpdateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body"})
+	if err := decodeStrictJSON(r, &req); err != nil {
+		h.respondDecodeError(w, r, err)
+		return
+	}
+
+	if req.Title == nil && req.Done == nil && req.Priority == nil && req.Description == nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "nothing to update: set title, done, priority or description"})
+		return
+	}
+
+	if req.Title != nil {
+		title, err := h.normalizeTitle(*req.Title)
+		if err != nil {
+			h.respondValidationError(w, r, err)
+			return
+		}
+		req.Title = &title
+	}
+
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		req.Description = &description
+	}
+
+	if err := validateUpdate(&req); err != nil {
+		h.respondValidationError(w, r, err)
+		return
+	}
+
+	patch := store.TaskPatch{Title: req.Title, Done: req.Done, Priority: req.Priority, Description: req.Description}
+	if conditional {
+		patch.IfVersion = version
+	}
+
+	if upsert && req.Title != nil && !conditional {
+		h.upsertTask(w, r, id, patch)
+		return
+	}
+
+	err = h.store.Patch(id, patch)
+
+	if errors.Is(err, store.ErrTaskNotFound) && upsert && !conditional {
+		h.respondValidationError(w, r, &ValidationError{Fields: []models.FieldError{
+			{Field: "title", Message: "is required to create a missing task"},
+		}})
+		return
+	} else if errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+		return
+	} else if errors.Is(err, store.ErrVersionConflict) {
+		h.respond(w, r, http.StatusPreconditionFailed, models.ErrorResponse{
+			Error: "task was modified since it was read",
+			Code:  "version_conflict",
+		})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, models.SuccessResponse{Updated: true})
+}
+
+// upsertTask applies patch to task id, creating the task from the patched
+// fields if it doesn't exist. patch must have a validated title.
+func (h *TaskHandler) upsertTask(w http.ResponseWriter, r *http.Request, id int, patch store.TaskPatch) {
+	if id > store.MaxUpsertID {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("id must be at most %d to create a task", store.MaxUpsertID),
+		})
+		return
+	}
+
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
+		return
+	}
+
+	draft := models.Task{Title: *patch.Title, CreatedBy: middleware.Identity(r.Context())}
+	if patch.Done != nil {
+		draft.Done = *patch.Done
+	}
+	if patch.Priority != nil {
+		draft.Priority = *patch.Priority
+	}
+	if patch.Description != nil {
+		draft.Description = *patch.Description
+	}
+
+	task, created, err := h.store.Upsert(id, patch, draft)
+	if errors.Is(err, store.ErrIDTaken) {
+		h.respond(w, r, http.StatusConflict, models.ErrorResponse{
+			Error: "task was deleted; restore it instead",
+			Code:  "task_deleted",
+		})
+		return
+	} else if errors.Is(err, store.ErrInvalidID) {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "invalid id"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	if created {
+		h.respondTask(w, r, http.StatusCreated, task, pres)
+		return
+	}
+	h.respond(w, r, http.StatusOK, models.SuccessResponse{Updated: true})
+}
+
+// ReplaceTask handles PUT /v1/tasks/{id} and PUT /v1/tasks?id=X
+// @Summary Replace a task
+// @Description Replace a task's title and done status
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param task body models.ReplaceTaskRequest true "Replacement task"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /v1/tasks/{id} [put]
+func (h *TaskHandler) ReplaceTask(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "id") {
 		return
 	}
 
-	if err := h.store.Update(id, req.Done); err == store.ErrTaskNotFound {
-		respondJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+	idStr := idParam(r)
+	if idStr == "" {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "id parameter is required"})
+		return
+	}
+
+	id, err := h.parseID(idStr)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var req models.ReplaceTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.respondDecodeError(w, r, err)
+		return
+	}
+
+	title, err := h.normalizeTitle(req.Title)
+	if err != nil {
+		h.respondValidationError(w, r, err)
+		return
+	}
+
+	if err := h.store.ReplaceTask(id, title, req.Done); errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
 		return
 	}
 
-	respondJSON(w, http.StatusOK, models.SuccessResponse{Updated: true})
+	h.respond(w, r, http.StatusOK, models.SuccessResponse{Updated: true})
 }
 
-// DeleteTask handles DELETE /v1/tasks?id=X
+// DeleteTask handles DELETE /v1/tasks/{id} and DELETE /v1/tasks?id=X
 // @Summary Delete a task
-// @Description Delete task by ID
+// @Description Soft-delete a task by ID. It is hidden from reads until restored with
+// @Description POST /v1/tasks/{id}/restore.
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param id query int true "Task ID"
+// @Param id path int true "Task ID"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /v1/tasks [delete]
+// @Router /v1/tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
+	if !h.checkQueryParams(w, r, "id") {
+		return
+	}
+
+	idStr := idParam(r)
 	if idStr == "" {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "id parameter is required"})
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: "id parameter is required"})
+		return
+	}
+
+	id, err := h.parseID(idStr)
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.store.Delete(id); errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, models.SuccessResponse{Updated: true})
+}
+
+// RestoreTask handles POST /v1/tasks/{id}/restore
+// @Summary Restore a deleted task
+// @Description Undo a soft delete. Restoring a task that isn't deleted returns it unchanged.
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/tasks/{id}/restore [post]
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	if !h.checkQueryParams(w, r, "expand") {
 		return
 	}
 
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 {
-		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid id"})
+	id, err := h.parseID(idParam(r))
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	if err := h.store.Delete(id); err == store.ErrTaskNotFound {
-		respondJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+	pres, err := parsePresentation(r)
+	if err != nil {
+		h.respondPresentationError(w, r, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, models.SuccessResponse{Updated: true})
+	task, err := h.store.Restore(id)
+	if errors.Is(err, store.ErrTaskNotFound) {
+		h.respond(w, r, http.StatusNotFound, models.ErrorResponse{Error: "task not found"})
+		return
+	} else if err != nil {
+		h.respond(w, r, http.StatusInternalServerError, models.ErrorResponse{Error: "internal error"})
+		return
+	}
+
+	h.respondTask(w, r, http.StatusOK, task, pres)
+}
+
+// isNil also catches typed nil pointers stored in the interface.
+func isNil(s store.Store) bool {
+	if s == nil {
+		return true
+	}
+	v := reflect.ValueOf(s)
+	return v.Kind() == reflect.Ptr && v.IsNil()
 }
 
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+// newTask builds the task described by a create request, normalizing the
+// title and validating every field with ValidateTask. A due date in the past
+// is rejected as a bad request rather than a validation failure, since it
+// depends on the clock rather than on the task alone. The task is attributed
+// to r's authenticated identity.
+func (h *TaskHandler) newTask(r *http.Request, req models.CreateTaskRequest) (models.Task, error) {
+	task := models.Task{
+		CreatedBy:   middleware.Identity(r.Context()),
+		Title:       h.titles.Normalize(req.Title),
+		Priority:    req.Priority,
+		DueDate:     req.DueDate,
+		Tags:        normalizeTags(req.Tags),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := ValidateTask(&task); err != nil {
+		return models.Task{}, err
+	}
+
+	if task.DueDate != nil && task.DueDate.Before(h.clock.Now()) {
+		return models.Task{}, errors.New("dueDate must not be in the past")
+	}
+
+	return task, nil
+}
+
+// normalizeTags trims and lowercases tags and drops duplicates, keeping the
+// first occurrence of each.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// normalizeTitle applies the configured normalization and validates the
+// result with ValidateTask.
+func (h *TaskHandler) normalizeTitle(title string) (string, error) {
+	title = h.titles.Normalize(title)
+	if err := ValidateTask(&models.Task{Title: title}); err != nil {
+		return "", err
+	}
+
+	return title, nil
 }
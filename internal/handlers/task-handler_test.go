@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"practice-one/internal/store"
+)
+
+// TestUpdateTaskConcurrentIfMatch sends several PATCHes that all read
+// version 1 at once. Exactly one may apply; the others must see the
+// conflict and get 412 instead of overwriting it.
+func TestUpdateTaskConcurrentIfMatch(t *testing.T) {
+	s := store.NewTaskStore()
+	task := s.Create("original")
+	h := NewTaskHandler(s)
+
+	const writers = 8
+	codes := make([]int, writers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPatch, "/v1/tasks?id=1", strings.NewReader(`{"title":"edited"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", `"1"`)
+			rec := httptest.NewRecorder()
+
+			<-start
+			h.UpdateTask(rec, req)
+			codes[i] = rec.Code
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	var applied, conflicts int
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			applied++
+		case http.StatusPreconditionFailed:
+			conflicts++
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if applied != 1 || conflicts != writers-1 {
+		t.Fatalf("got %d applied and %d conflicts, want 1 and %d", applied, conflicts, writers-1)
+	}
+
+	got, err := s.GetByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 2 {
+		t.Errorf("version = %d after one applied update, want 2", got.Version)
+	}
+}
+
+func TestUpdateTaskIfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		ifMatch     string
+		wantStatus  int
+		wantVersion int // version of task 1 afterwards
+	}{
+		{"unconditional", "1", "", http.StatusOK, 3},
+		{"wildcard is unconditional", "1", "*", http.StatusOK, 3},
+		{"current version", "1", `"2"`, http.StatusOK, 3},
+		{"unquoted version", "1", "2", http.StatusOK, 3},
+		{"vendor representation tag", "1", `"2-v2"`, http.StatusOK, 3},
+		{"stale vendor representation tag", "1", `"1-v2"`, http.StatusPreconditionFailed, 2},
+		{"stale version", "1", `"1"`, http.StatusPreconditionFailed, 2},
+		{"future version", "1", `"5"`, http.StatusPreconditionFailed, 2},
+		{"not a version", "1", `"abc"`, http.StatusBadRequest, 2},
+		{"version zero", "1", `"0"`, http.StatusBadRequest, 2},
+		{"missing task", "9", `"1"`, http.StatusNotFound, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewTaskStore()
+			task := s.Create("original")
+			done := true
+			if err := s.Patch(task.ID, store.TaskPatch{Done: &done}); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPatch, "/v1/tasks?id="+tt.id, strings.NewReader(`{"title":"edited"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			NewTaskHandler(s).UpdateTask(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			got, err := s.GetByID(task.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", got.Version, tt.wantVersion)
+			}
+		})
+	}
+}
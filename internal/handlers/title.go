@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TitleCase selects the casing applied to task titles.
+type TitleCase string
+
+const (
+	TitleCaseNone  TitleCase = ""
+	TitleCaseLower TitleCase = "lower"
+	TitleCaseTitle TitleCase = "title"
+)
+
+// TitleNormalizer tidies task titles before they are validated and stored.
+// Titles are always trimmed; collapsing and casing are opt-in.
+type TitleNormalizer struct {
+	// CollapseSpaces replaces internal runs of whitespace with a single space.
+	CollapseSpaces bool
+	Case           TitleCase
+}
+
+func (n TitleNormalizer) Normalize(title string) string {
+	title = strings.TrimSpace(title)
+
+	if n.CollapseSpaces {
+		title = strings.Join(strings.Fields(title), " ")
+	}
+
+	switch n.Case {
+	case TitleCaseLower:
+		title = strings.ToLower(title)
+	case TitleCaseTitle:
+		title = toTitleCase(title)
+	}
+
+	return title
+}
+
+// toTitleCase upper-cases the first letter of every word and lower-cases the rest.
+func toTitleCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	startOfWord := true
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+
+		if unicode.IsSpace(r) {
+			startOfWord = true
+			b.WriteRune(r)
+			continue
+		}
+
+		if startOfWord {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		startOfWord = false
+	}
+
+	return b.String()
+}
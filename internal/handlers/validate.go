@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"practice-one/internal/models"
+)
+
+// ValidationError lists every invalid field found in a task.
+type ValidationError struct {
+	Fields []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i := range e.Fields {
+		msgs[i] = e.Fields[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateTask checks every client-supplied field of task in a single pass
+// and returns a *ValidationError listing all violations, or nil if the task
+// is valid. Fields are expected to be normalized already.
+func ValidateTask(task *models.Task) error {
+	var fields []models.FieldError
+
+	switch {
+	case task.Title == "":
+		fields = append(fields, models.FieldError{Field: "title", Message: "must not be empty"})
+	case len(task.Title) > MaxTitleLength:
+		fields = append(fields, models.FieldError{
+			Field:   "title",
+			Message: fmt.Sprintf("must be at most %d characters", MaxTitleLength),
+		})
+	}
+
+	if task.Priority != "" && !validPriority(task.Priority) {
+		fields = append(fields, models.FieldError{Field: "priority", Message: "must be one of low, medium or high"})
+	}
+
+	if len(task.Description) > MaxDescriptionLength {
+		fields = append(fields, descriptionTooLong)
+	}
+
+	if len(task.Tags) > MaxTags {
+		fields = append(fields, models.FieldError{Field: "tags", Message: fmt.Sprintf("must have at most %d tags", MaxTags)})
+	}
+	for _, tag := range task.Tags {
+		if tag == "" || len(tag) > MaxTagLength {
+			fields = append(fields, models.FieldError{
+				Field:   "tags",
+				Message: fmt.Sprintf("each tag must be 1 to %d characters", MaxTagLength),
+			})
+			break
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// validateUpdate checks the fields set in a PATCH body the same way
+// ValidateTask checks a new task.
+func validateUpdate(req *models.UpdateTaskRequest) error {
+	var fields []models.FieldError
+
+	if req.Priority != nil && !validPriority(*req.Priority) {
+		fields = append(fields, models.FieldError{Field: "priority", Message: "must be one of low, medium or high"})
+	}
+	if req.Description != nil && len(*req.Description) > MaxDescriptionLength {
+		fields = append(fields, descriptionTooLong)
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+var descriptionTooLong = models.FieldError{
+	Field:   "description",
+	Message: fmt.Sprintf("must be at most %d characters", MaxDescriptionLength),
+}
+
+func validPriority(priority string) bool {
+	switch priority {
+	case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// respondValidationError answers with 422 and the full list of invalid fields
+// when err is a *ValidationError, and with 400 otherwise.
+func (h *TaskHandler) respondValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		h.respond(w, r, http.StatusUnprocessableEntity, models.ErrorResponse{
+			Error:  "validation failed",
+			Code:   "validation_failed",
+			Fields: verr.Fields,
+		})
+		return
+	}
+	h.respond(w, r, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+}
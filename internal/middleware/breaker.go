@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"practice-one/internal/clock"
+	"practice-one/internal/models"
+)
+
+const (
+	breakerClosed int32 = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker fails fast with 503 after threshold consecutive 5xx
+// responses. Once cooldown has passed it lets a single probe request through
+// (half-open): a non-5xx probe closes the breaker again, a 5xx reopens it for
+// another cooldown. Auth and rate limiting run inside the breaker, so 401 and
+// 429 responses say nothing about the handlers and are ignored.
+type CircuitBreaker struct {
+	threshold int32
+	cooldown  time.Duration
+	clock     clock.Clock
+
+	state    atomic.Int32
+	failures atomic.Int32
+	openedAt atomic.Int64 // UnixNano of the last trip
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration, c clock.Clock) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: int32(threshold),
+		cooldown:  cooldown,
+		clock:     c,
+	}
+}
+
+// allow reports whether a request may proceed and whether it is the
+// half-open probe, plus how long until the breaker may half-open.
+func (cb *CircuitBreaker) allow() (ok, probe bool, wait time.Duration) {
+	switch cb.state.Load() {
+	case breakerClosed:
+		return true, false, 0
+	case breakerOpen:
+		elapsed := cb.clock.Now().Sub(time.Unix(0, cb.openedAt.Load()))
+		if elapsed < cb.cooldown {
+			return false, false, cb.cooldown - elapsed
+		}
+		if cb.state.CompareAndSwap(breakerOpen, breakerHalfOpen) {
+			return true, true, 0
+		}
+	}
+
+	// Another request is already probing.
+	return false, false, cb.cooldown
+}
+
+func (cb *CircuitBreaker) record(status int, probe bool) {
+	if status == http.StatusUnauthorized || status == http.StatusTooManyRequests {
+		if probe {
+			// Hand the probe to the next request.
+			cb.state.Store(breakerOpen)
+		}
+		return
+	}
+
+	if status >= 500 {
+		if probe || cb.failures.Add(1) >= cb.threshold {
+			cb.trip()
+		}
+		return
+	}
+
+	cb.failures.Store(0)
+	if probe {
+		cb.state.Store(breakerClosed)
+	}
+}
+
+func (cb *CircuitBreaker) trip() {
+	cb.openedAt.Store(cb.clock.Now().UnixNano())
+	cb.failures.Store(0)
+	cb.state.Store(breakerOpen)
+}
+
+func (cb *CircuitBreaker) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, probe, wait := cb.allow()
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error: "service temporarily unavailable",
+				Code:  "circuit_open",
+			})
+			return
+		}
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() {
+			// A panicking handler counts as a 5xx; otherwise a panicking
+			// probe would leave the breaker half-open for good.
+			if p := recover(); p != nil {
+				cb.record(http.StatusInternalServerError, probe)
+				panic(p)
+			}
+			cb.record(wrapped.statusCode, probe)
+		}()
+		next.ServeHTTP(wrapped, r)
+	})
+}
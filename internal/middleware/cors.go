@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Content-Type, Content-Encoding, X-API-Key, X-Request-ID, If-Match, If-None-Match, Range, Prefer, traceparent, baggage"
+	corsExposeHeaders = "ETag, Link, X-Request-ID, Content-Range, Retry-After"
+)
+
+// CORS lets browser clients on allowedOrigins call the API. The request's
+// Origin is echoed back only if it is listed; a "*" entry allows any origin
+// and is sent as-is. Preflight requests are answered with 204 here, before
+// authentication, since browsers never send credentials on them.
+func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+	wildcard := slices.Contains(allowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && origin != "" &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+
+			if origin != "" {
+				w.Header().Add("Vary", "Origin")
+
+				switch {
+				case wildcard:
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				case slices.ContainsFunc(allowedOrigins, func(o string) bool { return strings.EqualFold(o, origin) }):
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+
+			if w.Header().Get("Access-Control-Allow-Origin") != "" {
+				if preflight {
+					w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+					w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				} else {
+					w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+				}
+			}
+
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"practice-one/internal/clock"
+	"practice-one/internal/models"
+)
+
+// ErrorRecorder keeps the last N server error (5xx) responses in a ring
+// buffer for quick debugging. Only the method, path, status, request ID and
+// time are kept; query strings, headers and bodies are never recorded.
+type ErrorRecorder struct {
+	mu      sync.Mutex
+	entries []models.ErrorRecord
+	next    int
+	full    bool
+	clock   clock.Clock
+}
+
+func NewErrorRecorder(size int, c clock.Clock) *ErrorRecorder {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &ErrorRecorder{
+		entries: make([]models.ErrorRecord, size),
+		clock:   c,
+	}
+}
+
+// Record is the middleware that captures 5xx responses. It must run inside
+// the NewRequestID middleware to see the request ID.
+func (er *ErrorRecorder) Record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		next.ServeHTTP(wrapped, r)
+
+		if wrapped.statusCode >= 500 {
+			requestID, _ := r.Context().Value(RequestIDKey).(string)
+			er.add(models.ErrorRecord{
+				RequestID: requestID,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Status:    wrapped.statusCode,
+				Time:      er.clock.Now(),
+			})
+		}
+	})
+}
+
+func (er *ErrorRecorder) add(record models.ErrorRecord) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	er.entries[er.next] = record
+	er.next = (er.next + 1) % len(er.entries)
+	if er.next == 0 {
+		er.full = true
+	}
+}
+
+// Recent returns the recorded errors, newest first.
+func (er *ErrorRecorder) Recent() []models.ErrorRecord {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	count := er.next
+	if er.full {
+		count = len(er.entries)
+	}
+
+	records := make([]models.ErrorRecord, 0, count)
+	for i := 1; i <= count; i++ {
+		idx := (er.next - i + len(er.entries)) % len(er.entries)
+		records = append(records, er.entries[idx])
+	}
+
+	return records
+}
+
+// Handler serves GET /v1/_admin/errors.
+func (er *ErrorRecorder) Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(er.Recent())
+}
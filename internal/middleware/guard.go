@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// SafeMethodGuard flags GET and HEAD requests whose handler writes to the
+// store. writes reports the running count of store writes, e.g.
+// store.WriteTrackingStore.Writes.
+//
+// A safe request runs alone: it excludes every other request while it is
+// being served, so any write counted meanwhile must be its own. That
+// serialization makes the guard a development aid, not something to run in
+// production.
+type SafeMethodGuard struct {
+	mu         sync.RWMutex
+	writes     func() uint64
+	violations atomic.Uint64
+}
+
+func NewSafeMethodGuard(writes func() uint64) *SafeMethodGuard {
+	return &SafeMethodGuard{writes: writes}
+}
+
+// Violations returns how many safe requests have written to the store.
+func (g *SafeMethodGuard) Violations() uint64 {
+	return g.violations.Load()
+}
+
+func (g *SafeMethodGuard) Check(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			g.mu.RLock()
+			defer g.mu.RUnlock()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		before := g.writes()
+		next.ServeHTTP(w, r)
+		if n := g.writes() - before; n > 0 {
+			g.violations.Add(1)
+			log.Printf("safe-method violation: %s %s made %d store write(s)", r.Method, r.URL.Path, n)
+		}
+	})
+}
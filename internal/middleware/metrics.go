@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"practice-one/internal/clock"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration
+// histogram; the same defaults the Prometheus client libraries use.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts requests, responses by status code and request durations,
+// and serves them in the Prometheus text exposition format.
+type Metrics struct {
+	mu       sync.Mutex
+	requests uint64
+	statuses map[int]uint64
+	buckets  []uint64 // cumulative counts, one per durationBuckets entry
+	sum      float64  // total duration in seconds
+	clock    clock.Clock
+}
+
+func NewMetrics(c clock.Clock) *Metrics {
+	return &Metrics{
+		statuses: make(map[int]uint64),
+		buckets:  make([]uint64, len(durationBuckets)),
+		clock:    c,
+	}
+}
+
+// Collect is the middleware that records each request once its handler
+// returns. It should run near the outside of the chain so rejected requests
+// (401, 429) are counted too.
+func (m *Metrics) Collect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := m.clock.Now()
+
+		wrapped := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		next.ServeHTTP(wrapped, r)
+
+		m.observe(wrapped.statusCode, m.clock.Now().Sub(start))
+	})
+}
+
+func (m *Metrics) observe(status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seconds := duration.Seconds()
+	m.requests++
+	m.statuses[status]++
+	m.sum += seconds
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			m.buckets[i]++
+		}
+	}
+}
+
+// Handler serves GET /metrics.
+func (m *Metrics) Handler(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	requests := m.requests
+	statuses := make(map[int]uint64, len(m.statuses))
+	for status, count := range m.statuses {
+		statuses[status] = count
+	}
+	buckets := slices.Clone(m.buckets)
+	sum := m.sum
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintln(w, "# HELP http_requests_total Total number of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	fmt.Fprintf(w, "http_requests_total %d\n", requests)
+
+	codes := make([]int, 0, len(statuses))
+	for status := range statuses {
+		codes = append(codes, status)
+	}
+	slices.Sort(codes)
+
+	fmt.Fprintln(w, "# HELP http_responses_total Number of HTTP responses by status code.")
+	fmt.Fprintln(w, "# TYPE http_responses_total counter")
+	for _, status := range codes {
+		fmt.Fprintf(w, "http_responses_total{code=\"%d\"} %d\n", status, statuses[status])
+	}
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Time taken to serve HTTP requests.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+	for i, bound := range durationBuckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{le=\"%s\"} %d\n", le, buckets[i])
+	}
+	fmt.Fprintf(w, "http_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", requests)
+	fmt.Fprintf(w, "http_request_duration_seconds_sum %s\n", strconv.FormatFloat(sum, 'g', -1, 64))
+	fmt.Fprintf(w, "http_request_duration_seconds_count %d\n", requests)
+}
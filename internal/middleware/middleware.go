@@ -1,42 +1,134 @@
 package middleware
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"practice-one/internal/clock"
 	"practice-one/internal/models"
 )
 
 type contextKey string
 
-const RequestIDKey contextKey = "requestID"
+const (
+	RequestIDKey contextKey = "requestID"
+	IdentityKey  contextKey = "identity"
+	logFieldsKey contextKey = "logFields"
+)
 
-var requestIDCounter int
-var requestIDMu sync.Mutex
+// logFields is filled in by inner middlewares so the Logger, which runs
+// outermost, can include values that only become known later in the chain.
+// Inner middlewares may run on another goroutine (see Timeout) that outlives
+// the Logger's read, so access goes through the mutex.
+type logFields struct {
+	mu        sync.Mutex
+	identity  string
+	requestID string
+}
 
-func APIKeyAuth(validKeys map[string]bool) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			apiKey := r.Header.Get("X-API-KEY")
+func (f *logFields) set(fn func(f *logFields)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
 
-			if apiKey == "" || !validKeys[apiKey] {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusUnauthorized)
-				json.NewEncoder(w).Encode(models.ErrorResponse{Error: "unauthorized"})
-				return
-			}
+func (f *logFields) get() (identity, requestID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.identity, f.requestID
+}
 
-			next.ServeHTTP(w, r)
-		})
-	}
+// APIKeyAuth accepts requests carrying a known X-API-KEY. validKeys maps each
+// key to the identity name it represents; the name (never the key itself) is
+// stored in the request context and included in the request log.
+func APIKeyAuth(validKeys map[string]string) func(http.Handler) http.Handler {
+	return NewAPIKeys(validKeys).Auth
 }
 
-func Logger(next http.Handler) http.Handler {
+// APIKeys is a set of accepted API keys that can be replaced while the
+// server runs, e.g. on a configuration reload.
+type APIKeys struct {
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+func NewAPIKeys(keys map[string]string) *APIKeys {
+	return &APIKeys{keys: keys}
+}
+
+// Set replaces the accepted keys. Requests already past Auth are unaffected.
+func (k *APIKeys) Set(keys map[string]string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+}
+
+func (k *APIKeys) lookup(apiKey string) (string, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	identity, ok := k.keys[apiKey]
+	return identity, ok
+}
+
+// Auth is the middleware behind APIKeyAuth, checking against the current keys.
+func (k *APIKeys) Auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-KEY")
+
+		identity, ok := k.lookup(apiKey)
+		if apiKey == "" || !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "unauthorized"})
+			return
+		}
+
+		if fields, ok := r.Context().Value(logFieldsKey).(*logFields); ok {
+			fields.set(func(f *logFields) { f.identity = identity })
+		}
+
+		ctx := context.WithValue(r.Context(), IdentityKey, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Identity returns the authenticated identity name for the request, or an
+// empty string when the request was not authenticated.
+func Identity(ctx context.Context) string {
+	identity, _ := ctx.Value(IdentityKey).(string)
+	return identity
+}
+
+// requestLog is what the request loggers record about one request.
+type requestLog struct {
+	method    string
+	path      string
+	status    int
+	bytes     int64
+	duration  time.Duration
+	requestID string
+	identity  string
+	remoteIP  string
+}
+
+// logRequests serves next and hands a summary of each request to write.
+func logRequests(next http.Handler, write func(entry requestLog)) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -45,55 +137,275 @@ func Logger(next http.Handler) http.Handler {
 			statusCode:     http.StatusOK,
 		}
 
+		fields := &logFields{}
+		r = r.WithContext(context.WithValue(r.Context(), logFieldsKey, fields))
+
 		next.ServeHTTP(wrapped, r)
 
-		duration := time.Since(start)
-		requestID := r.Context().Value(RequestIDKey)
+		identity, requestID := fields.get()
+		write(requestLog{
+			method:    r.Method,
+			path:      r.URL.Path,
+			status:    wrapped.statusCode,
+			bytes:     wrapped.bytesWritten,
+			duration:  time.Since(start),
+			requestID: requestID,
+			identity:  identity,
+			remoteIP:  ClientIP(r, false),
+		})
+	})
+}
+
+// Logger logs one human-readable line per request.
+func Logger(next http.Handler) http.Handler {
+	return logRequests(next, func(entry requestLog) {
+		identity := entry.identity
+		if identity == "" {
+			identity = "-"
+		}
 
-		log.Printf("%s %s %s [%d] [%s] [RequestID: %v]",
+		log.Printf("%s %s %s %s [%d] [%d bytes] [%s] [RequestID: %s] [Identity: %s]",
 			time.Now().Format("2006-01-02T15:04:05"),
-			r.Method,
-			r.URL.Path,
-			wrapped.statusCode,
-			duration,
-			requestID,
+			entry.remoteIP,
+			entry.method,
+			entry.path,
+			entry.status,
+			entry.bytes,
+			entry.duration,
+			entry.requestID,
+			identity,
 		)
 	})
 }
 
-func RequestID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestIDMu.Lock()
-		requestIDCounter++
-		reqID := fmt.Sprintf("req-%d", requestIDCounter)
-		requestIDMu.Unlock()
+// jsonLogLine is the object JSONLogger writes for each request.
+type jsonLogLine struct {
+	Time       string  `json:"time"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	Bytes      int64   `json:"bytes"`
+	DurationMS float64 `json:"duration_ms"`
+	RequestID  string  `json:"request_id,omitempty"`
+	Identity   string  `json:"identity,omitempty"`
+	RemoteIP   string  `json:"remote_ip"`
+}
+
+// JSONLogger is Logger for log aggregators: it writes one JSON object per
+// request, without the log package's prefix, to the standard logger's output.
+func JSONLogger(next http.Handler) http.Handler {
+	return logRequests(next, func(entry requestLog) {
+		line, err := json.Marshal(jsonLogLine{
+			Time:       time.Now().Format(time.RFC3339),
+			Method:     entry.method,
+			Path:       entry.path,
+			Status:     entry.status,
+			Bytes:      entry.bytes,
+			DurationMS: float64(entry.duration.Microseconds()) / 1000,
+			RequestID:  entry.requestID,
+			Identity:   entry.identity,
+			RemoteIP:   entry.remoteIP,
+		})
+		if err != nil {
+			return
+		}
+		log.Writer().Write(append(line, '\n'))
+	})
+}
+
+// NewRequestID returns middleware that tags each request with an ID of the
+// form req-<boot>-<n>, where boot is a random token chosen once per call and
+// n counts requests. The counter restarts with the process, so the token
+// keeps IDs from repeating across restarts while staying easy to read.
+func NewRequestID() func(http.Handler) http.Handler {
+	boot := bootToken()
+	var counter atomic.Uint64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqID := fmt.Sprintf("req-%s-%d", boot, counter.Add(1))
 
-		ctx := context.WithValue(r.Context(), RequestIDKey, reqID)
+			ctx := context.WithValue(r.Context(), RequestIDKey, reqID)
+			if fields, ok := r.Context().Value(logFieldsKey).(*logFields); ok {
+				fields.set(func(f *logFields) { f.requestID = reqID })
+			}
 
-		w.Header().Set("X-Request-ID", reqID)
+			w.Header().Set("X-Request-ID", reqID)
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bootToken returns 8 random hex characters, falling back to the start time
+// if the system random source fails.
+func bootToken() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// RejectBodyOnGetDelete answers 400 to GET and DELETE requests that carry a
+// non-empty body, which would otherwise be silently ignored.
+func RejectBodyOnGetDelete(next http.Handler) http.Handler {
+	return RejectBodyOnGetDeleteExcept()(next)
+}
+
+// RejectBodyOnGetDeleteExcept is RejectBodyOnGetDelete for all paths other
+// than allowed, whose handlers read a DELETE body (e.g. bulk deletes).
+func RejectBodyOnGetDeleteExcept(allowed ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodDelete {
+				if !slices.Contains(allowed, r.URL.Path) && hasBody(r) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(models.ErrorResponse{
+						Error: fmt.Sprintf("%s requests must not have a body", r.Method),
+					})
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasBody(r *http.Request) bool {
+	if r.ContentLength > 0 {
+		return true
+	}
+	if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+
+	// Unknown length (e.g. chunked): peek a single byte.
+	var b [1]byte
+	n, _ := r.Body.Read(b[:])
+	if n > 0 {
+		return true
+	}
+	r.Body = http.NoBody
+	return false
 }
 
 type RateLimiter struct {
 	mu       sync.Mutex
 	visitors map[string]*visitor
 	rate     int
+	refill   time.Duration
 	cleanup  time.Duration
+	clock    clock.Clock
+	keyFunc  func(*http.Request) string
+	// trustProxy takes the client IP from X-Forwarded-For or X-Real-IP.
+	trustProxy bool
+
+	// stop ends the cleanup goroutine, which closes done on exit.
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 type visitor struct {
-	tokens     int
+	tokens     float64
 	lastSeen   time.Time
 	lastRefill time.Time
 }
 
-func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+// RateLimiterOption configures optional RateLimiter behavior.
+type RateLimiterOption func(*RateLimiter)
+
+// WithRefillInterval sets the refill granularity. Every interval a visitor
+// gets rate*interval/minute tokens back, capped at rate, so throughput stays
+// at the configured per-minute rate without bursting at minute boundaries.
+// Passing time.Minute restores the old full-reset behavior.
+func WithRefillInterval(interval time.Duration) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		if interval > 0 {
+			rl.refill = interval
+		}
+	}
+}
+
+// WithKeyFunc sets how requests are grouped into token buckets. Requests for
+// which fn returns "" are bucketed by client IP, which is also the default.
+func WithKeyFunc(fn func(*http.Request) string) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.keyFunc = fn
+	}
+}
+
+// WithTrustProxy makes the limiter take the client IP from the
+// X-Forwarded-For or X-Real-IP header. Only enable it behind a proxy that
+// sets these headers, since clients can forge them otherwise.
+func WithTrustProxy(trust bool) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.trustProxy = trust
+	}
+}
+
+// LimitKey is a rate limiter key function (see WithKeyFunc) that buckets
+// requests by their X-API-KEY, so clients behind a shared proxy get separate
+// limits. The limiter runs before authentication, so only keys in k get their
+// own bucket; requests with a missing or unknown key fall back to the client
+// IP, which stops a client from getting a fresh bucket per made-up key.
+func (k *APIKeys) LimitKey(r *http.Request) string {
+	key := r.Header.Get("X-API-KEY")
+	if key == "" {
+		return ""
+	}
+	if _, ok := k.lookup(key); !ok {
+		return ""
+	}
+	return "key:" + key
+}
+
+// ClientIP returns the IP address of the client that sent r, without the
+// port. With trustProxy set, the first address in X-Forwarded-For, or else
+// X-Real-IP, takes precedence over the connection's remote address.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// WithClock sets the clock used for refills and visitor expiry.
+func WithClock(c clock.Clock) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.clock = c
+	}
+}
+
+func NewRateLimiter(requestsPerMinute int, opts ...RateLimiterOption) *RateLimiter {
 	rl := &RateLimiter{
 		visitors: make(map[string]*visitor),
 		rate:     requestsPerMinute,
+		refill:   time.Second,
 		cleanup:  5 * time.Minute,
+		clock:    clock.Real{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(rl)
 	}
 
 	go rl.cleanupVisitors()
@@ -101,69 +413,144 @@ func NewRateLimiter(requestsPerMinute int) *RateLimiter {
 	return rl
 }
 
+// SetRate changes the limit and refill interval for all visitors. Tokens
+// already held are capped at the new rate.
+func (rl *RateLimiter) SetRate(requestsPerMinute int, refill time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.rate = requestsPerMinute
+	if refill > 0 {
+		rl.refill = refill
+	}
+	for _, v := range rl.visitors {
+		if v.tokens > float64(rl.rate) {
+			v.tokens = float64(rl.rate)
+		}
+	}
+}
+
+// Stop ends the background cleanup of idle visitors and waits for it to
+// exit. The limiter keeps limiting afterwards; idle visitors just stay in
+// memory. Stop may be called more than once.
+func (rl *RateLimiter) Stop() {
+	rl.stopOnce.Do(func() { close(rl.stop) })
+	<-rl.done
+}
+
 func (rl *RateLimiter) cleanupVisitors() {
+	defer close(rl.done)
+
 	ticker := time.NewTicker(rl.cleanup)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-rl.stop:
+			return
+		case <-ticker.C:
+		}
+
 		rl.mu.Lock()
-		for ip, v := range rl.visitors {
-			if time.Since(v.lastSeen) > rl.cleanup {
-				delete(rl.visitors, ip)
+		now := rl.clock.Now()
+		for key, v := range rl.visitors {
+			if now.Sub(v.lastSeen) > rl.cleanup {
+				delete(rl.visitors, key)
 			}
 		}
 		rl.mu.Unlock()
 	}
 }
 
-func (rl *RateLimiter) getVisitor(ip string) *visitor {
+func (rl *RateLimiter) getVisitor(key string) *visitor {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	v, exists := rl.visitors[ip]
+	v, exists := rl.visitors[key]
 	if !exists {
+		now := rl.clock.Now()
 		v = &visitor{
-			tokens:     rl.rate,
-			lastSeen:   time.Now(),
-			lastRefill: time.Now(),
+			tokens:     float64(rl.rate),
+			lastSeen:   now,
+			lastRefill: now,
 		}
-		rl.visitors[ip] = v
+		rl.visitors[key] = v
 	}
 
 	return v
 }
 
-func (rl *RateLimiter) allow(ip string) bool {
-	v := rl.getVisitor(ip)
+// allow takes a token for key if one is available. Otherwise it reports how
+// long until the next token is earned.
+func (rl *RateLimiter) allow(key string) (bool, time.Duration) {
+	v := rl.getVisitor(key)
 
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
-	if now.Sub(v.lastRefill) >= time.Minute {
-		v.tokens = rl.rate
-		v.lastRefill = now
-	}
+	now := rl.clock.Now()
+	rl.refillTokens(v, now)
 
 	v.lastSeen = now
 
-	if v.tokens > 0 {
+	if v.tokens >= 1 {
 		v.tokens--
-		return true
+		return true, 0
 	}
 
-	return false
+	return false, rl.nextToken(v, now)
+}
+
+// nextToken returns the time until v holds a whole token again. Callers must
+// hold rl.mu.
+func (rl *RateLimiter) nextToken(v *visitor, now time.Time) time.Duration {
+	perStep := float64(rl.rate) * rl.refill.Seconds() / time.Minute.Seconds()
+	if perStep <= 0 {
+		return time.Minute
+	}
+
+	steps := math.Ceil((1 - v.tokens) / perStep)
+	return v.lastRefill.Add(time.Duration(steps) * rl.refill).Sub(now)
+}
+
+// refillTokens adds the tokens earned by whole refill intervals elapsed since
+// the last refill. Callers must hold rl.mu.
+func (rl *RateLimiter) refillTokens(v *visitor, now time.Time) {
+	steps := now.Sub(v.lastRefill) / rl.refill
+	if steps <= 0 {
+		return
+	}
+
+	perStep := float64(rl.rate) * rl.refill.Seconds() / time.Minute.Seconds()
+	v.tokens += float64(steps) * perStep
+	if v.tokens > float64(rl.rate) {
+		v.tokens = float64(rl.rate)
+	}
+	v.lastRefill = v.lastRefill.Add(steps * rl.refill)
 }
 
 func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
+		var key string
+		if rl.keyFunc != nil {
+			key = rl.keyFunc(r)
+		}
+		if key == "" {
+			key = "ip:" + ClientIP(r, rl.trustProxy)
+		}
 
-		if !rl.allow(ip) {
+		if ok, wait := rl.allow(key); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusTooManyRequests)
 			json.NewEncoder(w).Encode(models.ErrorResponse{
-				Error: "rate limit exceeded",
+				Error:      "rate limit exceeded",
+				RetryAfter: retryAfter,
 			})
 			return
 		}
@@ -174,7 +561,8 @@ func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
 
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -182,9 +570,54 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Write counts the body bytes actually written, for the request log.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush, Hijack and Push delegate to the underlying writer so handlers that
+// type-assert for http.Flusher, http.Hijacker or http.Pusher (streaming,
+// WebSockets) keep working behind the Logger. Flush is a no-op and the
+// others return http.ErrNotSupported when the underlying writer can't.
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
+}
+
+func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
+	p, ok := rw.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return p.Push(target, opts)
+}
+
+// Chain composes middlewares so the first one runs outermost. Nil entries are
+// skipped, which lets callers pass optional middlewares that are disabled.
 func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
 	return func(final http.Handler) http.Handler {
 		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] == nil {
+				continue
+			}
 			final = middlewares[i](final)
 		}
 		return final
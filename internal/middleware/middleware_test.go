@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"practice-one/internal/clock"
+)
+
+// tag returns a middleware that appends name to the X-Order header before
+// calling next, so tests can see the order middlewares ran in.
+func tag(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Order", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name        string
+		middlewares []func(http.Handler) http.Handler
+		want        string
+	}{
+		{"no middlewares", nil, "final"},
+		{"only nil", []func(http.Handler) http.Handler{nil, nil}, "final"},
+		{"first runs outermost", []func(http.Handler) http.Handler{tag("a"), tag("b")}, "a,b,final"},
+		{"nil entries skipped", []func(http.Handler) http.Handler{nil, tag("a"), nil, tag("b"), nil}, "a,b,final"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Order", "final")
+			})
+
+			rec := httptest.NewRecorder()
+			Chain(tt.middlewares...)(final).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := strings.Join(rec.Header().Values("X-Order"), ","); got != tt.want {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     int
+		refill   time.Duration
+		elapsed  time.Duration
+		want     int           // requests allowed after elapsed
+		wantWait time.Duration // Retry-After wait once those are used up
+	}{
+		{"no time passed", 60, time.Second, 0, 0, time.Second},
+		{"partial interval earns nothing", 60, time.Second, 500 * time.Millisecond, 0, 500 * time.Millisecond},
+		{"one interval", 60, time.Second, time.Second, 1, time.Second},
+		{"proportional to elapsed time", 60, time.Second, 10 * time.Second, 10, time.Second},
+		{"capped at rate", 60, time.Second, 2 * time.Minute, 60, time.Second},
+		{"fractional tokens accumulate", 30, time.Second, 3 * time.Second, 1, time.Second},
+		{"minute interval waits for the reset", 60, time.Minute, 59 * time.Second, 0, time.Second},
+		{"minute interval resets fully", 60, time.Minute, time.Minute, 60, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			rl := NewRateLimiter(tt.rate, WithRefillInterval(tt.refill), WithClock(fake))
+			defer rl.Stop()
+
+			for i := 0; i < tt.rate; i++ {
+				if ok, _ := rl.allow("k"); !ok {
+					t.Fatalf("request %d of the initial burst was limited", i+1)
+				}
+			}
+
+			fake.Advance(tt.elapsed)
+
+			allowed := 0
+			for {
+				ok, wait := rl.allow("k")
+				if !ok {
+					if wait != tt.wantWait {
+						t.Errorf("wait = %v, want %v", wait, tt.wantWait)
+					}
+					break
+				}
+				allowed++
+				if allowed > tt.rate {
+					t.Fatal("allowed more than rate requests")
+				}
+			}
+			if allowed != tt.want {
+				t.Errorf("allowed %d requests after %v, want %d", allowed, tt.elapsed, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"practice-one/internal/clock"
+	"practice-one/internal/models"
+)
+
+// DailyQuota caps the number of requests each API key identity may make per
+// UTC day. It is independent of the per-minute RateLimiter and must run
+// after APIKeyAuth so the identity is known.
+type DailyQuota struct {
+	mu     sync.Mutex
+	limit  int
+	clock  clock.Clock
+	day    time.Time // UTC midnight the counts belong to
+	counts map[string]int
+}
+
+func NewDailyQuota(limit int, c clock.Clock) *DailyQuota {
+	return &DailyQuota{
+		limit:  limit,
+		clock:  c,
+		counts: make(map[string]int),
+	}
+}
+
+// allow counts a request for key and reports whether it is within quota,
+// along with the time the quota resets.
+func (q *DailyQuota) allow(key string) (bool, time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.clock.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !today.Equal(q.day) {
+		q.day = today
+		q.counts = make(map[string]int)
+	}
+
+	resetAt := today.AddDate(0, 0, 1)
+	if q.counts[key] >= q.limit {
+		return false, resetAt
+	}
+
+	q.counts[key]++
+	return true, resetAt
+}
+
+func (q *DailyQuota) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := Identity(r.Context())
+		if key == "" {
+			key = r.Header.Get("X-API-KEY")
+		}
+
+		allowed, resetAt := q.allow(key)
+		if !allowed {
+			retryAfter := int(resetAt.Sub(q.clock.Now()).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error: "daily quota exceeded",
+				Code:  "quota_exceeded",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// Timeout cancels the request context after d. If the handler hasn't started
+// its response by then, the client gets 503 and anything the handler writes
+// afterwards is discarded; a response that is already streaming is left to
+// finish, since handlers see the cancellation through r.Context().
+//
+// Unlike http.TimeoutHandler the response is not buffered, so flushing still
+// works; hijacking does not.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return TimeoutExcept(d)
+}
+
+// TimeoutExcept is Timeout for all paths other than exempt, whose handlers
+// run long by design and enforce their own deadline (e.g. streaming imports).
+func TimeoutExcept(d time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				return
+			case <-ctx.Done():
+			}
+
+			tw.mu.Lock()
+			if !tw.wroteHeader {
+				tw.timedOut = true
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Error: "request timed out"})
+				tw.mu.Unlock()
+				return
+			}
+			tw.mu.Unlock()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+			}
+		})
+	}
+}
+
+// timeoutWriter keeps the handler's headers separate until it writes, so the
+// timeout response can't be mixed with a late one from the handler.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	for key, values := range tw.header {
+		tw.w.Header()[key] = values
+	}
+	tw.w.WriteHeader(code)
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
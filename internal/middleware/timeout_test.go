@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	const d = 20 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		path       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "fast handler passes through",
+			path: "/v1/tasks",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "yes")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("created"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "created",
+		},
+		{
+			name: "slow handler gets 503",
+			path: "/v1/tasks",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "request timed out",
+		},
+		{
+			name: "started response is left to finish",
+			path: "/v1/tasks",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("first,"))
+				<-r.Context().Done()
+				w.Write([]byte("second"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "first,second",
+		},
+		{
+			name: "exempt path has no deadline",
+			path: "/v1/tasks/import",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+					w.WriteHeader(http.StatusInternalServerError)
+				case <-time.After(2 * d):
+					w.Write([]byte("done"))
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			TimeoutExcept(d, "/v1/tasks/import")(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTimeoutDiscardsLateWrites(t *testing.T) {
+	served := make(chan struct{})
+	written := make(chan error, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-served // the 503 has been sent
+		w.Header().Set("X-Late", "yes")
+		_, err := w.Write([]byte("late"))
+		written <- err
+	})
+
+	rec := httptest.NewRecorder()
+	Timeout(10*time.Millisecond)(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	close(served)
+
+	if err := <-written; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("late write error = %v, want http.ErrHandlerTimeout", err)
+	}
+	if rec.Header().Get("X-Late") != "" || strings.Contains(rec.Body.String(), "late") {
+		t.Errorf("late write reached the client: headers %v, body %q", rec.Header(), rec.Body.String())
+	}
+}
+
+func TestTimeoutRepanics(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v, want the handler's panic", p)
+		}
+	}()
+	Timeout(time.Second)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
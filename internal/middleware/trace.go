@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const TraceKey contextKey = "trace"
+
+// TraceContext holds the W3C trace context and baggage received with a request.
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+	TraceID     string
+	ParentID    string
+	Flags       string
+
+	// Baggage holds the parsed baggage members; RawBaggage keeps the header
+	// as received (including member properties) for propagation.
+	Baggage    map[string]string
+	RawBaggage string
+}
+
+// Trace parses the traceparent, tracestate and baggage headers and stores
+// them in the request context. Invalid traceparent values are ignored, as
+// the W3C spec requires.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc := &TraceContext{
+			RawBaggage: r.Header.Get("baggage"),
+			Baggage:    parseBaggage(r.Header.Get("baggage")),
+		}
+
+		if parent := r.Header.Get("traceparent"); parent != "" {
+			if traceID, parentID, flags, ok := parseTraceParent(parent); ok {
+				tc.TraceParent = parent
+				tc.TraceState = r.Header.Get("tracestate")
+				tc.TraceID = traceID
+				tc.ParentID = parentID
+				tc.Flags = flags
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), TraceKey, tc)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// TraceFromContext returns the trace context stored by Trace, if any.
+func TraceFromContext(ctx context.Context) (*TraceContext, bool) {
+	tc, ok := ctx.Value(TraceKey).(*TraceContext)
+	return tc, ok
+}
+
+// InjectTrace copies the trace context and baggage from ctx onto an outbound
+// request, e.g. a webhook delivery, so the call can be correlated.
+func InjectTrace(ctx context.Context, req *http.Request) {
+	tc, ok := TraceFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	if tc.TraceParent != "" {
+		req.Header.Set("traceparent", tc.TraceParent)
+		if tc.TraceState != "" {
+			req.Header.Set("tracestate", tc.TraceState)
+		}
+	}
+
+	if tc.RawBaggage != "" {
+		req.Header.Set("baggage", tc.RawBaggage)
+	}
+}
+
+// parseTraceParent validates a "version-traceid-parentid-flags" header.
+func parseTraceParent(value string) (traceID, parentID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", "", "", false
+	}
+
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", "", false
+	}
+	if !isHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return "", "", "", false
+	}
+	if !isHex(parentID, 16) || parentID == strings.Repeat("0", 16) {
+		return "", "", "", false
+	}
+	if !isHex(flags, 2) {
+		return "", "", "", false
+	}
+
+	return traceID, parentID, flags, true
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// parseBaggage parses "key1=value1;prop,key2=value2" into a map, dropping
+// member properties and malformed members.
+func parseBaggage(header string) map[string]string {
+	baggage := make(map[string]string)
+	if header == "" {
+		return baggage
+	}
+
+	for _, member := range strings.Split(header, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		key, value, ok := strings.Cut(member, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		baggage[key] = decoded
+	}
+
+	return baggage
+}
@@ -1,23 +1,317 @@
 package models
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
 type Task struct {
-	ID    int    `json:"id"`
-	Title string `json:"title"`
-	Done  bool   `json:"done"`
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// CreatedBy is the identity of the API key that created the task, or
+	// CreatedByAnonymous.
+	CreatedBy string `json:"createdBy"`
+	// Version starts at 1 and is incremented on every change to the task.
+	// Send it back in If-Match to make an update conditional.
+	Version int `json:"version"`
+	// Priority is one of PriorityLow, PriorityMedium or PriorityHigh.
+	Priority string `json:"priority"`
+	// DueDate is optional; a task without one is never overdue.
+	DueDate *time.Time `json:"dueDate,omitempty"`
+	// Tags are lowercase labels such as "work"; never null in responses.
+	Tags        []string `json:"tags"`
+	Description string   `json:"description,omitempty"`
+	// DeletedAt is set while the task is soft-deleted and can be restored.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// IsOverdue reports whether the task is incomplete and was due before now.
+func (t *Task) IsOverdue(now time.Time) bool {
+	return !t.Done && t.DueDate != nil && t.DueDate.Before(now)
+}
+
+// CreatedByAnonymous is recorded for tasks created without an authenticated
+// identity, including tasks saved before CreatedBy existed.
+const CreatedByAnonymous = "anonymous"
+
+// Task priorities. Tasks created without one get PriorityMedium.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// ExpandedTask is a task with derived fields, returned for ?expand=computed.
+type ExpandedTask struct {
+	Task
+	AgeSeconds int64 `json:"age_seconds"`
+	IsOverdue  bool  `json:"is_overdue"`
+}
+
+// ScoredTask is a search hit together with its relevance score.
+type ScoredTask struct {
+	Task
+	Score int `json:"score"`
+}
+
+// ScoredTaskV2 is a search hit in the v2 representation.
+type ScoredTaskV2 struct {
+	TaskV2
+	Score int `json:"score"`
+}
+
+// TaskV2 is the task representation served for
+// Accept: application/vnd.tasks.v2+json.
+type TaskV2 struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Completed   bool       `json:"completed"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CreatedBy   string     `json:"createdBy"`
+	Version     int        `json:"version"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Tags        []string   `json:"tags"`
+	Description string     `json:"description,omitempty"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	AgeSeconds  *int64     `json:"age_seconds,omitempty"`
+	IsOverdue   *bool      `json:"is_overdue,omitempty"`
 }
 
 type CreateTaskRequest struct {
+	Title    string `json:"title"`
+	Priority string `json:"priority,omitempty"`
+	// DueDate is an RFC 3339 timestamp that must not be in the past.
+	DueDate *time.Time `json:"dueDate,omitempty"`
+	// Tags are lowercased and deduplicated.
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// ReplaceTaskRequest is the body of PUT /v1/tasks/:id; every field is replaced.
+type ReplaceTaskRequest struct {
 	Title string `json:"title"`
+	Done  bool   `json:"done"`
 }
 
+// UnmarshalJSON accepts done in the same forms as UpdateTaskRequest. An
+// omitted or null done replaces it with false.
+func (r *ReplaceTaskRequest) UnmarshalJSON(data []byte) error {
+	type plain ReplaceTaskRequest
+	aux := struct {
+		*plain
+		Done json.RawMessage `json:"done"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.Done = false
+	if len(aux.Done) == 0 || bytes.Equal(aux.Done, []byte("null")) {
+		return nil
+	}
+
+	done, err := unmarshalLenientBool("done", aux.Done)
+	if err != nil {
+		return err
+	}
+	r.Done = done
+	return nil
+}
+
+// UpdateTaskRequest is the body of PATCH /v1/tasks/:id. Only the fields
+// present in the body are changed. In upsert mode a missing task is created
+// from the body, which then needs a title.
 type UpdateTaskRequest struct {
-	Done bool `json:"done"`
+	Title       *string `json:"title,omitempty"`
+	Done        *bool   `json:"done,omitempty"`
+	Priority    *string `json:"priority,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// UnmarshalJSON accepts done either as a JSON boolean or as a quoted boolean
+// string such as "true", which some clients send. Unknown fields are
+// rejected, since a decoder's DisallowUnknownFields does not reach into a
+// custom unmarshaler.
+func (u *UpdateTaskRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateTaskRequest
+	aux := struct {
+		*plain
+		Done json.RawMessage `json:"done"`
+	}{plain: (*plain)(u)}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&aux); err != nil {
+		return err
+	}
+
+	// null leaves done unset, like the other optional fields.
+	if len(aux.Done) == 0 || bytes.Equal(aux.Done, []byte("null")) {
+		return nil
+	}
+
+	done, err := unmarshalLenientBool("done", aux.Done)
+	if err != nil {
+		return err
+	}
+	u.Done = &done
+	return nil
+}
+
+func unmarshalLenientBool(field string, raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return false, &FieldError{Field: field, Message: "must be a boolean"}
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, &FieldError{Field: field, Message: fmt.Sprintf("invalid boolean %q", s)}
+	}
+	return b, nil
+}
+
+// FieldError describes a problem with a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type MergeTasksRequest struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string       `json:"error"`
+	Code   string       `json:"code,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+	// RetryAfter repeats the Retry-After header, in seconds, on 429s.
+	RetryAfter int `json:"retry_after,omitempty"`
 }
 
 type SuccessResponse struct {
 	Updated bool `json:"updated"`
 }
+
+// TaskPage is one page of the task list.
+type TaskPage struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// TaskDelta lists what changed since a point in time, for delta sync.
+type TaskDelta struct {
+	Items   interface{} `json:"items"`
+	Deleted []int       `json:"deleted"`
+}
+
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+// SnapshotResponse describes the store state for cheap change detection.
+type SnapshotResponse struct {
+	Hash     string `json:"hash"`
+	Revision uint64 `json:"revision"`
+	Count    int    `json:"count"`
+}
+
+type GroupedStatsResponse struct {
+	By     string         `json:"by"`
+	Counts map[string]int `json:"counts"`
+}
+
+// BulkItemResult reports the outcome of one item of a partial bulk create.
+type BulkItemResult struct {
+	Index  int `json:"index"`
+	Status int `json:"status"`
+	// Task is the created task in the negotiated representation.
+	Task  interface{} `json:"task,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+type BulkCreateResponse struct {
+	Results []BulkItemResult `json:"results"`
+}
+
+// BulkUpdateItem is one entry of PATCH /v1/tasks/bulk. Version is the
+// version the client last saw; the update only applies if it still matches.
+type BulkUpdateItem struct {
+	ID       int     `json:"id"`
+	Version  int     `json:"version"`
+	Done     *bool   `json:"done,omitempty"`
+	Priority *string `json:"priority,omitempty"`
+}
+
+// BulkUpdateResult reports the outcome of one item of a bulk update:
+// applied, conflict, not_found or invalid. Version is the task's version
+// after the update, or its current version on a conflict.
+type BulkUpdateResult struct {
+	ID      int    `json:"id"`
+	Status  int    `json:"status"`
+	Outcome string `json:"outcome"`
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BulkUpdateResponse struct {
+	Results []BulkUpdateResult `json:"results"`
+}
+
+type BulkDeleteRequest struct {
+	IDs []int `json:"ids"`
+}
+
+type BulkDeleteResponse struct {
+	Deleted  int   `json:"deleted"`
+	NotFound []int `json:"not_found"`
+}
+
+// ImportResult is one line of the NDJSON import response.
+type ImportResult struct {
+	Index   int    `json:"index"`
+	ID      int    `json:"id,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportSummary is the last line of the NDJSON import response.
+type ImportSummary struct {
+	Summary ImportCounts `json:"summary"`
+}
+
+type ImportCounts struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// ErrorRecord is a server error response captured for debugging.
+type ErrorRecord struct {
+	RequestID string    `json:"requestId"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Time      time.Time `json:"time"`
+}
@@ -1,65 +1,353 @@
 package router
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strings"
 )
 
+// standardMethods are the methods registered by Any.
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// Route is a registered handler together with optional documentation that
+// is surfaced by RoutesHandler.
+type Route struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	// MaxBodyBytes caps the request body; zero uses the router default.
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+
+	handler  http.HandlerFunc
+	segments []string
+}
+
+// Doc attaches a human-readable summary and description to the route.
+func (rt *Route) Doc(summary, description string) *Route {
+	rt.Summary = summary
+	rt.Description = description
+	return rt
+}
+
+// MaxBody overrides the router's default request body limit for this route,
+// e.g. to let an import endpoint accept more than a single create.
+func (rt *Route) MaxBody(n int64) *Route {
+	rt.MaxBodyBytes = n
+	return rt
+}
+
 type Router struct {
-	routes map[string]map[string]http.HandlerFunc // method -> path -> handler
+	routes map[string]map[string]*Route // method -> path -> route
+
+	// maxBody is the request body limit for routes without their own; zero
+	// means unlimited.
+	maxBody int64
+}
+
+// SetMaxBody sets the default request body limit in bytes. Bodies over the
+// limit fail to read with *http.MaxBytesError. Zero means unlimited.
+func (r *Router) SetMaxBody(n int64) {
+	r.maxBody = n
 }
 
 func NewRouter() *Router {
 	return &Router{
-		routes: make(map[string]map[string]http.HandlerFunc),
+		routes: make(map[string]map[string]*Route),
 	}
 }
 
-func (r *Router) Handle(method, path string, handler http.HandlerFunc) {
+func (r *Router) Handle(method, path string, handler http.HandlerFunc) *Route {
 	if r.routes[method] == nil {
-		r.routes[method] = make(map[string]http.HandlerFunc)
+		r.routes[method] = make(map[string]*Route)
 	}
-	r.routes[method][path] = handler
+
+	route := &Route{Method: method, Path: path, handler: handler, segments: splitPath(path)}
+	r.routes[method][path] = route
+	return route
+}
+
+// Methods registers handler for every method in methods on path.
+func (r *Router) Methods(methods []string, path string, handler http.HandlerFunc) {
+	for _, method := range methods {
+		r.Handle(method, path, handler)
+	}
+}
+
+// Any registers handler for all standard HTTP methods on path.
+func (r *Router) Any(path string, handler http.HandlerFunc) {
+	r.Methods(standardMethods, path, handler)
 }
 
-func (r *Router) GET(path string, handler http.HandlerFunc) {
-	r.Handle(http.MethodGet, path, handler)
+func (r *Router) GET(path string, handler http.HandlerFunc) *Route {
+	return r.Handle(http.MethodGet, path, handler)
 }
 
-func (r *Router) POST(path string, handler http.HandlerFunc) {
-	r.Handle(http.MethodPost, path, handler)
+func (r *Router) POST(path string, handler http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPost, path, handler)
 }
 
-func (r *Router) PATCH(path string, handler http.HandlerFunc) {
-	r.Handle(http.MethodPatch, path, handler)
+func (r *Router) PUT(path string, handler http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPut, path, handler)
 }
 
-func (r *Router) DELETE(path string, handler http.HandlerFunc) {
-	r.Handle(http.MethodDelete, path, handler)
+func (r *Router) PATCH(path string, handler http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPatch, path, handler)
+}
+
+func (r *Router) DELETE(path string, handler http.HandlerFunc) *Route {
+	return r.Handle(http.MethodDelete, path, handler)
 }
 
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if IsServerOptions(req) {
+		r.ServerOptions(w, req)
+		return
+	}
+
 	path := req.URL.Path
 	if idx := strings.Index(path, "?"); idx != -1 {
 		path = path[:idx]
 	}
 
-	if handlers, ok := r.routes[req.Method]; ok {
-		if handler, ok := handlers[path]; ok {
-			handler(w, req)
-			return
+	route, params := r.match(r.routes[req.Method], path)
+	if route == nil && req.Method == http.MethodHead {
+		// HEAD is served by the GET handler unless registered explicitly,
+		// with the body it writes discarded.
+		if route, params = r.match(r.routes[http.MethodGet], path); route != nil {
+			w = &headWriter{ResponseWriter: w}
+		}
+	}
+
+	if route != nil {
+		if len(params) > 0 {
+			req = req.WithContext(context.WithValue(req.Context(), paramsKey{}, params))
+		}
+		if limit := route.bodyLimit(r.maxBody); limit > 0 && req.Body != nil {
+			req.Body = http.MaxBytesReader(w, req.Body, limit)
+			req = req.WithContext(context.WithValue(req.Context(), bodyLimitKey{}, limit))
 		}
+		route.handler(w, req)
+		return
+	}
+
+	if allowed := r.allowedMethods(path); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
 	}
 
 	http.NotFound(w, req)
 }
 
+func (rt *Route) bodyLimit(fallback int64) int64 {
+	if rt.MaxBodyBytes > 0 {
+		return rt.MaxBodyBytes
+	}
+	return fallback
+}
+
+// allowedMethods returns the sorted methods registered for path, including
+// HEAD wherever GET is.
+func (r *Router) allowedMethods(path string) []string {
+	var allowed []string
+	for method, routes := range r.routes {
+		if route, _ := r.match(routes, path); route != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return withHead(allowed)
+}
+
+// withHead adds HEAD to methods if GET is among them, and sorts them.
+func withHead(methods []string) []string {
+	if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// headWriter discards the body a GET handler writes when it serves HEAD.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// match finds the route for path among routes. An exact path wins outright;
+// otherwise segments like ":id" match any single non-empty segment and static
+// segments are preferred over parameters, so /v1/tasks/export beats
+// /v1/tasks/:id regardless of registration order.
+func (r *Router) match(routes map[string]*Route, path string) (*Route, map[string]string) {
+	if route, ok := routes[path]; ok {
+		return route, nil
+	}
+
+	segments := splitPath(path)
+
+	var best *Route
+	for _, route := range routes {
+		if !route.matches(segments) {
+			continue
+		}
+		if best == nil || moreSpecific(route, best) {
+			best = route
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	params := make(map[string]string)
+	for i, seg := range best.segments {
+		if name, ok := paramName(seg); ok {
+			params[name] = segments[i]
+		}
+	}
+	return best, params
+}
+
+func (rt *Route) matches(segments []string) bool {
+	if len(rt.segments) != len(segments) {
+		return false
+	}
+	for i, seg := range rt.segments {
+		if _, ok := paramName(seg); ok {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// moreSpecific reports whether a should be preferred over b. At the first
+// segment where one is static and the other a parameter, the static one
+// wins; routes that differ only in parameter names fall back to comparing
+// their paths so the choice never depends on map order.
+func moreSpecific(a, b *Route) bool {
+	for i := range a.segments {
+		_, aParam := paramName(a.segments[i])
+		_, bParam := paramName(b.segments[i])
+		if aParam != bParam {
+			return !aParam
+		}
+	}
+	return a.Path < b.Path
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func paramName(segment string) (string, bool) {
+	if len(segment) > 1 && segment[0] == ':' {
+		return segment[1:], true
+	}
+	return "", false
+}
+
+type paramsKey struct{}
+
+type bodyLimitKey struct{}
+
+// BodyLimit returns the body size limit the router applied to r, or 0 if
+// the body is unlimited. Handlers that decompress the body use it to cap the
+// decompressed size too.
+func BodyLimit(r *http.Request) int64 {
+	limit, _ := r.Context().Value(bodyLimitKey{}).(int64)
+	return limit
+}
+
+// Param returns the value of the path parameter name captured for r, e.g.
+// Param(r, "id") for a route registered as /v1/tasks/:id. It returns "" if
+// the route has no such parameter.
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
+
+// IsServerOptions reports whether req is an asterisk-form "OPTIONS *",
+// which asks about the server as a whole. The http.Server must set
+// DisableGeneralOptionsHandler for such requests to reach a handler.
+func IsServerOptions(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.RequestURI == "*"
+}
+
+// ServerOptions answers "OPTIONS *" with an Allow header listing every
+// method the router serves. The router answers it itself, but servers that
+// authenticate requests should answer it ahead of that, since it reveals
+// nothing about any resource.
+func (r *Router) ServerOptions(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Allow", strings.Join(r.serverMethods(), ", "))
+	w.WriteHeader(http.StatusOK)
+}
+
+// serverMethods returns every method registered on any path, plus OPTIONS
+// and, if GET is registered, HEAD.
+func (r *Router) serverMethods() []string {
+	methods := []string{http.MethodOptions}
+	for method := range r.routes {
+		if method != http.MethodOptions {
+			methods = append(methods, method)
+		}
+	}
+	return withHead(methods)
+}
+
+// Routes returns a copy of all registered routes ordered by path and method.
+func (r *Router) Routes() []Route {
+	var routes []Route
+	for _, byPath := range r.routes {
+		for _, route := range byPath {
+			routes = append(routes, *route)
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// RoutesHandler serves the registered routes and their documentation as JSON.
+func (r *Router) RoutesHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(r.Routes())
+}
+
 func (r *Router) PrintRoutes() {
 	fmt.Println("Registered routes:")
-	for method, paths := range r.routes {
-		for path := range paths {
-			fmt.Printf("  %s %s\n", method, path)
-		}
+	for _, route := range r.Routes() {
+		fmt.Printf("  %s %s\n", route.Method, route.Path)
 	}
 }
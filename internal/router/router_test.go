@@ -0,0 +1,104 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterMatch(t *testing.T) {
+	r := NewRouter()
+	for _, path := range []string{
+		"/v1/tasks",
+		"/v1/tasks/:id",
+		"/v1/tasks/export",
+		"/v1/tasks/:id/restore",
+		"/v1/:kind/summary",
+		"/v1/users/:user/tasks/:id",
+		"/v1/users/me/tasks/:id",
+	} {
+		r.GET(path, func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Route", path)
+			w.Header().Set("X-Id", Param(req, "id"))
+		})
+	}
+
+	tests := []struct {
+		path      string
+		wantRoute string // empty for 404
+		wantID    string
+	}{
+		{"/v1/tasks", "/v1/tasks", ""},
+		{"/v1/tasks/42", "/v1/tasks/:id", "42"},
+		{"/v1/tasks/export", "/v1/tasks/export", ""},
+		{"/v1/tasks/42/restore", "/v1/tasks/:id/restore", "42"},
+		{"/v1/tasks/summary", "/v1/tasks/:id", "summary"},
+		{"/v1/notes/summary", "/v1/:kind/summary", ""},
+		{"/v1/users/me/tasks/7", "/v1/users/me/tasks/:id", "7"},
+		{"/v1/users/ann/tasks/7", "/v1/users/:user/tasks/:id", "7"},
+		{"/v1/tasks/", "", ""},
+		{"/v1/tasks/42/other", "", ""},
+		{"/v2/tasks", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if tt.wantRoute == "" {
+				if rec.Code != http.StatusNotFound {
+					t.Fatalf("status = %d, want 404 (matched %q)", rec.Code, rec.Header().Get("X-Route"))
+				}
+				return
+			}
+			if got := rec.Header().Get("X-Route"); got != tt.wantRoute {
+				t.Errorf("route = %q, want %q", got, tt.wantRoute)
+			}
+			if got := rec.Header().Get("X-Id"); got != tt.wantID {
+				t.Errorf("id = %q, want %q", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMoreSpecific(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"/v1/tasks/export", "/v1/tasks/:id", true},
+		{"/v1/tasks/:id", "/v1/tasks/export", false},
+		// The first segment that differs in kind decides.
+		{"/v1/tasks/:action", "/v1/:kind/summary", true},
+		{"/v1/:kind/summary", "/v1/tasks/:action", false},
+		// Equally specific routes are ordered by path, so the winner doesn't
+		// depend on map iteration order.
+		{"/v1/a/:id", "/v1/b/:id", true},
+		{"/v1/b/:id", "/v1/a/:id", false},
+	}
+
+	for _, tt := range tests {
+		a := &Route{Path: tt.a, segments: splitPath(tt.a)}
+		b := &Route{Path: tt.b, segments: splitPath(tt.b)}
+		if got := moreSpecific(a, b); got != tt.want {
+			t.Errorf("moreSpecific(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	r := NewRouter()
+	r.GET("/v1/tasks/:id", func(w http.ResponseWriter, req *http.Request) {})
+	r.DELETE("/v1/tasks/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks/1", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+	if got, want := rec.Header().Get("Allow"), "DELETE, GET, HEAD"; got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+
+	"practice-one/internal/models"
+)
+
+// CachingStore wraps another Store with a bounded LRU cache for GetByID.
+// Entries are invalidated whenever the task is updated or deleted through
+// the CachingStore. Methods that are not cached are delegated to the inner
+// store unchanged.
+type CachingStore struct {
+	Store
+
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[int]*list.Element
+	gen     uint64 // bumped on every invalidation
+}
+
+var _ Store = (*CachingStore)(nil)
+
+type cacheEntry struct {
+	id   int
+	task models.Task
+}
+
+func NewCachingStore(inner Store, size int) *CachingStore {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &CachingStore{
+		Store:   inner,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[int]*list.Element),
+	}
+}
+
+func (c *CachingStore) GetByID(id int) (*models.Task, error) {
+	task, gen, ok := c.get(id)
+	if ok {
+		return task, nil
+	}
+
+	task, err := c.Store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(task, gen)
+	return task, nil
+}
+
+func (c *CachingStore) Exists(id int) bool {
+	if _, _, ok := c.get(id); ok {
+		return true
+	}
+	return c.Store.Exists(id)
+}
+
+func (c *CachingStore) Update(id int, done bool) error {
+	defer c.invalidate(id)
+	return c.Store.Update(id, done)
+}
+
+func (c *CachingStore) Patch(id int, patch TaskPatch) error {
+	defer c.invalidate(id)
+	return c.Store.Patch(id, patch)
+}
+
+func (c *CachingStore) Upsert(id int, patch TaskPatch, draft models.Task) (*models.Task, bool, error) {
+	defer c.invalidate(id)
+	return c.Store.Upsert(id, patch, draft)
+}
+
+func (c *CachingStore) UpdateManyOptimistic(updates []VersionedUpdate) []UpdateResult {
+	defer c.invalidateAll()
+	return c.Store.UpdateManyOptimistic(updates)
+}
+
+func (c *CachingStore) ReplaceTask(id int, title string, done bool) error {
+	defer c.invalidate(id)
+	return c.Store.ReplaceTask(id, title, done)
+}
+
+func (c *CachingStore) Delete(id int) error {
+	defer c.invalidate(id)
+	return c.Store.Delete(id)
+}
+
+func (c *CachingStore) Restore(id int) (*models.Task, error) {
+	defer c.invalidate(id)
+	return c.Store.Restore(id)
+}
+
+func (c *CachingStore) DeleteMany(ids []int) (int, []int) {
+	defer c.invalidateAll()
+	return c.Store.DeleteMany(ids)
+}
+
+func (c *CachingStore) Merge(sourceID, targetID int) (*models.Task, error) {
+	defer c.invalidate(sourceID)
+	defer c.invalidate(targetID)
+	return c.Store.Merge(sourceID, targetID)
+}
+
+// WithTransaction drops the whole cache since the transaction may touch any task.
+func (c *CachingStore) WithTransaction(fn func(tx TxStore) error) error {
+	defer c.invalidateAll()
+	return c.Store.WithTransaction(fn)
+}
+
+func (c *CachingStore) get(id int) (*models.Task, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, c.gen, false
+	}
+
+	c.order.MoveToFront(elem)
+	taskCopy := elem.Value.(*cacheEntry).task
+	return &taskCopy, c.gen, true
+}
+
+// put caches task unless an invalidation happened since gen was read, in
+// which case the value fetched from the inner store may already be stale.
+func (c *CachingStore) put(task *models.Task, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if elem, ok := c.entries[task.ID]; ok {
+		elem.Value.(*cacheEntry).task = *task
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[task.ID] = c.order.PushFront(&cacheEntry{id: task.ID, task: *task})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+}
+
+func (c *CachingStore) invalidate(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if elem, ok := c.entries[id]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+	}
+}
+
+func (c *CachingStore) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.order.Init()
+	c.entries = make(map[int]*list.Element)
+}
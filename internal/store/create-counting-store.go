@@ -0,0 +1,72 @@
+package store
+
+import (
+	"expvar"
+
+	"practice-one/internal/models"
+)
+
+// CreateCountingStore wraps another Store and adds the number of tasks each
+// call creates to an expvar counter, including tasks created by an upsert or
+// inside a transaction that commits.
+type CreateCountingStore struct {
+	Store
+
+	created *expvar.Int
+}
+
+var _ Store = (*CreateCountingStore)(nil)
+
+func NewCreateCountingStore(inner Store, created *expvar.Int) *CreateCountingStore {
+	return &CreateCountingStore{Store: inner, created: created}
+}
+
+func (s *CreateCountingStore) Create(title string) *models.Task {
+	task := s.Store.Create(title)
+	s.created.Add(1)
+	return task
+}
+
+func (s *CreateCountingStore) CreateTask(draft models.Task) *models.Task {
+	task := s.Store.CreateTask(draft)
+	s.created.Add(1)
+	return task
+}
+
+func (s *CreateCountingStore) CreateMany(drafts []models.Task) []*models.Task {
+	tasks := s.Store.CreateMany(drafts)
+	s.created.Add(int64(len(tasks)))
+	return tasks
+}
+
+func (s *CreateCountingStore) Upsert(id int, patch TaskPatch, draft models.Task) (*models.Task, bool, error) {
+	task, created, err := s.Store.Upsert(id, patch, draft)
+	if created {
+		s.created.Add(1)
+	}
+	return task, created, err
+}
+
+func (s *CreateCountingStore) WithTransaction(fn func(tx TxStore) error) error {
+	var n int64
+	err := s.Store.WithTransaction(func(tx TxStore) error {
+		return fn(&createCountingTx{TxStore: tx, n: &n})
+	})
+	if err == nil {
+		s.created.Add(n)
+	}
+	return err
+}
+
+// createCountingTx counts creates inside a transaction; they are only added
+// to the store's counter once the transaction commits.
+type createCountingTx struct {
+	TxStore
+
+	n *int64
+}
+
+func (tx *createCountingTx) Create(title string) *models.Task {
+	*tx.n++
+	return tx.TxStore.Create(title)
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"practice-one/internal/models"
+)
+
+// NewTaskStoreWithFile returns a TaskStore that loads its tasks from the JSON
+// file at path and rewrites the file after every mutation, so tasks survive
+// restarts. A missing file starts an empty store; an unreadable or corrupt
+// one is an error.
+func NewTaskStoreWithFile(path string, opts ...Option) (*TaskStore, error) {
+	s := NewTaskStore(opts...)
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("store: loading %s: %w", path, err)
+	}
+
+	var tasks []*models.Task
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("store: loading %s: %w", path, err)
+	}
+
+	for _, task := range tasks {
+		// Files saved by older versions lack the newer optional fields.
+		applyDefaults(task)
+		if task.DeletedAt != nil {
+			s.deleted[task.ID] = task
+		} else {
+			s.tasks[task.ID] = task
+		}
+		if task.ID >= s.nextID {
+			s.nextID = task.ID + 1
+		}
+	}
+
+	return s, nil
+}
+
+// changed records a mutation: it bumps the revision and, for file-backed
+// stores, saves the tasks. Callers must hold the write lock.
+func (s *TaskStore) changed() {
+	s.revision++
+
+	if s.path == "" {
+		return
+	}
+	if err := s.save(); err != nil {
+		log.Printf("store: saving %s: %v", s.path, err)
+	}
+}
+
+// save writes all tasks, soft-deleted ones included, ordered by id, to a
+// temporary file next to s.path and renames it into place so a crash never
+// leaves a partial file behind. Callers must hold the lock.
+func (s *TaskStore) save() error {
+	tasks := make([]*models.Task, 0, len(s.tasks)+len(s.deleted))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+	for _, task := range s.deleted {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
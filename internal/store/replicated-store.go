@@ -0,0 +1,156 @@
+package store
+
+import (
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// ReplicatedStore sends writes to a primary store and reads to a replica.
+//
+// Consistency is eventual: keeping the replica in sync with the primary is
+// the job of whatever replicates between them, so a read issued right after
+// a write may not observe it yet (e.g. a task just created can 404). Callers
+// that need read-your-writes must read from the primary directly.
+//
+// Every Store method is routed explicitly rather than by embedding, so a new
+// method can't silently default to the wrong side.
+type ReplicatedStore struct {
+	primary Store
+	replica Store
+}
+
+var _ Store = (*ReplicatedStore)(nil)
+
+func NewReplicatedStore(primary, replica Store) *ReplicatedStore {
+	return &ReplicatedStore{primary: primary, replica: replica}
+}
+
+// Writes go to the primary.
+
+func (s *ReplicatedStore) Create(title string) *models.Task {
+	return s.primary.Create(title)
+}
+
+func (s *ReplicatedStore) CreateTask(draft models.Task) *models.Task {
+	return s.primary.CreateTask(draft)
+}
+
+func (s *ReplicatedStore) CreateMany(drafts []models.Task) []*models.Task {
+	return s.primary.CreateMany(drafts)
+}
+
+func (s *ReplicatedStore) Update(id int, done bool) error {
+	return s.primary.Update(id, done)
+}
+
+func (s *ReplicatedStore) Patch(id int, patch TaskPatch) error {
+	return s.primary.Patch(id, patch)
+}
+
+func (s *ReplicatedStore) Upsert(id int, patch TaskPatch, draft models.Task) (*models.Task, bool, error) {
+	return s.primary.Upsert(id, patch, draft)
+}
+
+func (s *ReplicatedStore) UpdateManyOptimistic(updates []VersionedUpdate) []UpdateResult {
+	return s.primary.UpdateManyOptimistic(updates)
+}
+
+func (s *ReplicatedStore) ReplaceTask(id int, title string, done bool) error {
+	return s.primary.ReplaceTask(id, title, done)
+}
+
+func (s *ReplicatedStore) Delete(id int) error {
+	return s.primary.Delete(id)
+}
+
+func (s *ReplicatedStore) Restore(id int) (*models.Task, error) {
+	return s.primary.Restore(id)
+}
+
+func (s *ReplicatedStore) DeleteMany(ids []int) (int, []int) {
+	return s.primary.DeleteMany(ids)
+}
+
+func (s *ReplicatedStore) Merge(sourceID, targetID int) (*models.Task, error) {
+	return s.primary.Merge(sourceID, targetID)
+}
+
+func (s *ReplicatedStore) WithTransaction(fn func(tx TxStore) error) error {
+	return s.primary.WithTransaction(fn)
+}
+
+// Reads go to the replica.
+
+func (s *ReplicatedStore) GetByID(id int) (*models.Task, error) {
+	return s.replica.GetByID(id)
+}
+
+func (s *ReplicatedStore) Exists(id int) bool {
+	return s.replica.Exists(id)
+}
+
+func (s *ReplicatedStore) GetAll() []*models.Task {
+	return s.replica.GetAll()
+}
+
+func (s *ReplicatedStore) GetByStatus(done bool) []*models.Task {
+	return s.replica.GetByStatus(done)
+}
+
+func (s *ReplicatedStore) GetOverdue(now time.Time) []*models.Task {
+	return s.replica.GetOverdue(now)
+}
+
+func (s *ReplicatedStore) GetByTags(tags []string) []*models.Task {
+	return s.replica.GetByTags(tags)
+}
+
+func (s *ReplicatedStore) FindMap(filter Filter) map[int]*models.Task {
+	return s.replica.FindMap(filter)
+}
+
+func (s *ReplicatedStore) Find(filter Filter) []*models.Task {
+	return s.replica.Find(filter)
+}
+
+func (s *ReplicatedStore) GetSorted(field, order string) ([]*models.Task, error) {
+	return s.replica.GetSorted(field, order)
+}
+
+func (s *ReplicatedStore) GetPaged(filter Filter, order Sort, limit, offset int) ([]*models.Task, int) {
+	return s.replica.GetPaged(filter, order, limit, offset)
+}
+
+func (s *ReplicatedStore) GetModifiedSince(since time.Time) ([]*models.Task, []int) {
+	return s.replica.GetModifiedSince(since)
+}
+
+func (s *ReplicatedStore) Search(query string) []models.ScoredTask {
+	return s.replica.Search(query)
+}
+
+func (s *ReplicatedStore) Random(pendingOnly bool) (*models.Task, error) {
+	return s.replica.Random(pendingOnly)
+}
+
+func (s *ReplicatedStore) ForEach(fn func(task *models.Task) bool) {
+	s.replica.ForEach(fn)
+}
+
+func (s *ReplicatedStore) Count(filter Filter) int {
+	return s.replica.Count(filter)
+}
+
+func (s *ReplicatedStore) CountBy(field string) (map[string]int, error) {
+	return s.replica.CountBy(field)
+}
+
+func (s *ReplicatedStore) GroupBy(field string, filter Filter) (map[string][]*models.Task, error) {
+	return s.replica.GroupBy(field, filter)
+}
+
+// Revision reports the replica's revision so that ETags match what reads return.
+func (s *ReplicatedStore) Revision() uint64 {
+	return s.replica.Revision()
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"sort"
+	"strings"
+
+	"practice-one/internal/models"
+)
+
+// Relevance scores used by Search, highest first.
+const (
+	ScoreExact     = 3
+	ScorePrefix    = 2
+	ScoreSubstring = 1
+)
+
+// score rates how well title matches query, ignoring case: an exact match
+// beats a prefix match, which beats a match anywhere in the title. Zero
+// means no match.
+func score(title, query string) int {
+	title = strings.ToLower(title)
+	query = strings.ToLower(query)
+
+	switch {
+	case title == query:
+		return ScoreExact
+	case strings.HasPrefix(title, query):
+		return ScorePrefix
+	case strings.Contains(title, query):
+		return ScoreSubstring
+	}
+	return 0
+}
+
+// Search returns copies of the tasks whose title matches query, ordered by
+// descending score and then by id.
+func (s *TaskStore) Search(query string) []models.ScoredTask {
+	s.mu.RLock()
+	results := make([]models.ScoredTask, 0)
+	for _, task := range s.tasks {
+		if sc := score(task.Title, query); sc > 0 {
+			results = append(results, models.ScoredTask{Task: *task, Score: sc})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	return results
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	"practice-one/internal/models"
+)
+
+// Sort orders task lists. The zero value sorts by id ascending.
+type Sort struct {
+	Field string // "id", "title" or "created"
+	Desc  bool
+}
+
+// NewSort validates a sort field and order ("asc" or "desc"). Empty values
+// select id and asc.
+func NewSort(field, order string) (Sort, error) {
+	var s Sort
+
+	switch field {
+	case "", "id":
+		s.Field = "id"
+	case "title", "created":
+		s.Field = field
+	default:
+		return s, fmt.Errorf("sort field %q: %w", field, ErrInvalidSort)
+	}
+
+	switch order {
+	case "", "asc":
+	case "desc":
+		s.Desc = true
+	default:
+		return s, fmt.Errorf("sort order %q: %w", order, ErrInvalidSort)
+	}
+
+	return s, nil
+}
+
+// apply sorts tasks in place. Ties are broken by id so the order is always
+// deterministic.
+func (s Sort) apply(tasks []*models.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if s.Desc {
+			a, b = b, a
+		}
+
+		switch s.Field {
+		case "title":
+			if a.Title != b.Title {
+				return a.Title < b.Title
+			}
+		case "created":
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+		}
+		return a.ID < b.ID
+	})
+}
+
+// GetSorted returns copies of all tasks ordered by field and order; see
+// NewSort for the accepted values.
+func (s *TaskStore) GetSorted(field, order string) ([]*models.Task, error) {
+	srt, err := NewSort(field, order)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := s.GetAll()
+	srt.apply(tasks)
+	return tasks, nil
+}
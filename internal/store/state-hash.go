@@ -0,0 +1,53 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// StateHash returns a hex-encoded SHA-256 of the content of every task in s,
+// ordered by id. Unlike Revision it leaves out the version and timestamps,
+// which move on every write, so two stores holding the same tasks hash
+// equally and a mutation that is later undone restores the original hash.
+func StateHash(s Store) string {
+	var tasks []stateFields
+	s.ForEach(func(task *models.Task) bool {
+		tasks = append(tasks, stateFields{
+			ID:          task.ID,
+			Title:       task.Title,
+			Done:        task.Done,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+			Tags:        task.Tags,
+			Description: task.Description,
+			DeletedAt:   task.DeletedAt,
+		})
+		return true
+	})
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, task := range tasks {
+		// Encoding cannot fail; the fields are all plain values.
+		enc.Encode(task)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stateFields is the part of a task StateHash covers.
+type stateFields struct {
+	ID          int
+	Title       string
+	Done        bool
+	Priority    string
+	DueDate     *time.Time
+	Tags        []string
+	Description string
+	DeletedAt   *time.Time
+}
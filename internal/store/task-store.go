@@ -2,42 +2,209 @@ package store
 
 import (
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
+	"practice-one/internal/clock"
 	"practice-one/internal/models"
 )
 
+// Sentinel errors returned by stores. They may be wrapped with additional
+// context, so callers should match them with errors.Is.
 var (
 	ErrTaskNotFound = errors.New("task not found")
 	ErrInvalidID    = errors.New("invalid id")
+	ErrInvalidGroup = errors.New("invalid group")
+	ErrInvalidSort  = errors.New("invalid sort")
+	// ErrVersionConflict means a conditional update expected a version the
+	// task no longer has.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrIDTaken means a task can't be created with an id that belongs to
+	// a soft-deleted task.
+	ErrIDTaken = errors.New("id taken")
 )
 
+// MaxUpsertID is the largest id Upsert creates a task under: the largest
+// integer a JSON client using doubles can represent exactly. It also keeps
+// nextID from overflowing.
+const MaxUpsertID = 1<<53 - 1
+
+func notFound(id int) error {
+	return fmt.Errorf("task %d: %w", id, ErrTaskNotFound)
+}
+
+// Store is the set of task operations the handlers depend on. TaskStore is
+// the in-memory implementation; decorators such as CachingStore wrap another
+// Store to add behavior.
+type Store interface {
+	Create(title string) *models.Task
+	CreateTask(draft models.Task) *models.Task
+	CreateMany(drafts []models.Task) []*models.Task
+	GetByID(id int) (*models.Task, error)
+	Exists(id int) bool
+	GetAll() []*models.Task
+	GetByStatus(done bool) []*models.Task
+	GetOverdue(now time.Time) []*models.Task
+	GetByTags(tags []string) []*models.Task
+	Find(filter Filter) []*models.Task
+	FindMap(filter Filter) map[int]*models.Task
+	GetSorted(field, order string) ([]*models.Task, error)
+	GetPaged(filter Filter, order Sort, limit, offset int) ([]*models.Task, int)
+	GetModifiedSince(since time.Time) (tasks []*models.Task, deleted []int)
+	Search(query string) []models.ScoredTask
+	Random(pendingOnly bool) (*models.Task, error)
+	ForEach(fn func(task *models.Task) bool)
+	Count(filter Filter) int
+	CountBy(field string) (map[string]int, error)
+	GroupBy(field string, filter Filter) (map[string][]*models.Task, error)
+	Update(id int, done bool) error
+	Patch(id int, patch TaskPatch) error
+	Upsert(id int, patch TaskPatch, draft models.Task) (task *models.Task, created bool, err error)
+	UpdateManyOptimistic(updates []VersionedUpdate) []UpdateResult
+	ReplaceTask(id int, title string, done bool) error
+	Delete(id int) error
+	Restore(id int) (*models.Task, error)
+	DeleteMany(ids []int) (deleted int, notFound []int)
+	Merge(sourceID, targetID int) (*models.Task, error)
+	Revision() uint64
+	WithTransaction(fn func(tx TxStore) error) error
+}
+
 type TaskStore struct {
 	mu     sync.RWMutex
 	tasks  map[int]*models.Task
 	nextID int
+	clock  clock.Clock
+
+	// rand picks tasks for Random; randMu guards it since a Rand is not
+	// safe for concurrent use and Random only holds the read lock.
+	rand   *rand.Rand
+	randMu sync.Mutex
+
+	// deleted holds soft-deleted tasks, with DeletedAt set, until they are
+	// restored. Lookups only see tasks, so deleted tasks are hidden unless a
+	// Filter asks for them.
+	deleted map[int]*models.Task
+
+	// revision is bumped on every mutation so callers can cheaply tell
+	// whether anything changed.
+	revision uint64
+
+	// tombstones records when each deleted task was removed, so delta-sync
+	// clients can learn about deletions. They are kept for the lifetime of
+	// the process and are not persisted.
+	tombstones map[int]time.Time
+
+	// path is the JSON file the tasks are saved to after every mutation;
+	// empty for a purely in-memory store.
+	path string
+}
+
+// Option configures optional TaskStore behavior.
+type Option func(*TaskStore)
+
+// WithClock sets the clock used for task timestamps.
+func WithClock(c clock.Clock) Option {
+	return func(s *TaskStore) {
+		s.clock = c
+	}
 }
 
-func NewTaskStore() *TaskStore {
-	return &TaskStore{
-		tasks:  make(map[int]*models.Task),
-		nextID: 1,
+// WithRand sets the random source used by Random, e.g. a seeded one for
+// reproducible picks.
+func WithRand(r *rand.Rand) Option {
+	return func(s *TaskStore) {
+		s.rand = r
+	}
+}
+
+func NewTaskStore(opts ...Option) *TaskStore {
+	s := &TaskStore{
+		tasks:      make(map[int]*models.Task),
+		deleted:    make(map[int]*models.Task),
+		nextID:     1,
+		clock:      clock.Real{},
+		rand:       rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		tombstones: make(map[int]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 func (s *TaskStore) Create(title string) *models.Task {
+	return s.CreateTask(models.Task{Title: title})
+}
+
+// CreateTask stores a new task with the client-supplied fields of draft.
+// The id, timestamps and version are assigned by the store; see
+// applyDefaults for the fields draft may leave empty.
+func (s *TaskStore) CreateTask(draft models.Task) *models.Task {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	task := &models.Task{
-		ID:    s.nextID,
-		Title: title,
-		Done:  false,
-	}
+	now := s.clock.Now()
+	task := &draft
+	task.ID = s.nextID
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	task.Version = 1
+	applyDefaults(task)
 	s.tasks[s.nextID] = task
 	s.nextID++
+	s.changed()
+
+	taskCopy := *task
+	return &taskCopy
+}
+
+// CreateMany creates one task per draft, as CreateTask does, under a single
+// lock so the batch gets consecutive IDs.
+func (s *TaskStore) CreateMany(drafts []models.Task) []*models.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	created := make([]*models.Task, 0, len(drafts))
+	for _, draft := range drafts {
+		task := &draft
+		task.ID = s.nextID
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		task.Version = 1
+		applyDefaults(task)
+		s.tasks[s.nextID] = task
+		s.nextID++
 
-	return task
+		taskCopy := *task
+		created = append(created, &taskCopy)
+	}
+	s.changed()
+
+	return created
+}
+
+// applyDefaults fills in optional fields left empty: the priority becomes
+// medium, missing tags become an empty list, so they never encode as null,
+// and a task with no creator is recorded as anonymous.
+func applyDefaults(task *models.Task) {
+	if task.Priority == "" {
+		task.Priority = models.PriorityMedium
+	}
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+	if task.CreatedBy == "" {
+		task.CreatedBy = models.CreatedByAnonymous
+	}
 }
 
 func (s *TaskStore) GetByID(id int) (*models.Task, error) {
@@ -46,13 +213,22 @@ func (s *TaskStore) GetByID(id int) (*models.Task, error) {
 
 	task, exists := s.tasks[id]
 	if !exists {
-		return nil, ErrTaskNotFound
+		return nil, notFound(id)
 	}
 
 	taskCopy := *task
 	return &taskCopy, nil
 }
 
+// Exists reports whether a task with the given id is stored, without copying it.
+func (s *TaskStore) Exists(id int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.tasks[id]
+	return exists
+}
+
 func (s *TaskStore) GetAll() []*models.Task {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -81,27 +257,602 @@ func (s *TaskStore) GetByStatus(done bool) []*models.Task {
 	return tasks
 }
 
+// GetOverdue returns copies of the incomplete tasks whose due date is before
+// now, most overdue first. Tasks without a due date are never overdue.
+func (s *TaskStore) GetOverdue(now time.Time) []*models.Task {
+	tasks := s.Find(Filter{OverdueAt: now})
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if !a.DueDate.Equal(*b.DueDate) {
+			return a.DueDate.Before(*b.DueDate)
+		}
+		return a.ID < b.ID
+	})
+
+	return tasks
+}
+
+// GetByTags returns copies of the tasks carrying all of tags, ordered by id.
+func (s *TaskStore) GetByTags(tags []string) []*models.Task {
+	tasks := s.Find(Filter{Tags: tags})
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	return tasks
+}
+
+// CountBy counts tasks grouped by the given field in a single pass.
+// Supported fields: "done", "priority" and "tag"; a task counts once for
+// each of its tags.
+func (s *TaskStore) CountBy(field string) (map[string]int, error) {
+	var keys func(*models.Task) []string
+	switch field {
+	case "done":
+		keys = func(t *models.Task) []string { return []string{strconv.FormatBool(t.Done)} }
+	case "priority":
+		keys = func(t *models.Task) []string { return []string{t.Priority} }
+	case "tag":
+		keys = func(t *models.Task) []string { return t.Tags }
+	default:
+		return nil, fmt.Errorf("%q: %w", field, ErrInvalidGroup)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, task := range s.tasks {
+		for _, key := range keys(task) {
+			counts[key]++
+		}
+	}
+
+	return counts, nil
+}
+
+// GroupBy returns copies of the tasks matching filter split into sections,
+// collected in a single pass and ordered by id within each section.
+// Supported fields: "status" (sections pending and done), "priority" (low,
+// medium and high) and "tag", where a task appears under each of its tags.
+// The status and priority sections are always present, even when empty.
+func (s *TaskStore) GroupBy(field string, filter Filter) (map[string][]*models.Task, error) {
+	groups := make(map[string][]*models.Task)
+	var keys func(*models.Task) []string
+	switch field {
+	case "status":
+		groups["pending"] = []*models.Task{}
+		groups["done"] = []*models.Task{}
+		keys = func(t *models.Task) []string {
+			if t.Done {
+				return []string{"done"}
+			}
+			return []string{"pending"}
+		}
+	case "priority":
+		for _, priority := range []string{models.PriorityLow, models.PriorityMedium, models.PriorityHigh} {
+			groups[priority] = []*models.Task{}
+		}
+		keys = func(t *models.Task) []string { return []string{t.Priority} }
+	case "tag":
+		keys = func(t *models.Task) []string { return t.Tags }
+	default:
+		return nil, fmt.Errorf("%q: %w", field, ErrInvalidGroup)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.scan(filter, func(task *models.Task) {
+		for _, key := range keys(task) {
+			taskCopy := *task
+			groups[key] = append(groups[key], &taskCopy)
+		}
+	})
+
+	for _, tasks := range groups {
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	}
+
+	return groups, nil
+}
+
+// Filter selects tasks. Nil or zero fields match every task.
+type Filter struct {
+	Done     *bool
+	Priority string
+	// OverdueAt selects incomplete tasks whose due date is before it.
+	OverdueAt time.Time
+	// Tags selects tasks carrying every one of the tags.
+	Tags []string
+	// IncludeDeleted also matches soft-deleted tasks.
+	IncludeDeleted bool
+}
+
+func (f Filter) matches(task *models.Task) bool {
+	if f.Done != nil && task.Done != *f.Done {
+		return false
+	}
+	if f.Priority != "" && task.Priority != f.Priority {
+		return false
+	}
+	if !f.OverdueAt.IsZero() && !task.IsOverdue(f.OverdueAt) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(task.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// scan calls fn for every task matching filter, including soft-deleted
+// tasks when the filter asks for them. Callers must hold the lock.
+func (s *TaskStore) scan(filter Filter, fn func(task *models.Task)) {
+	for _, task := range s.tasks {
+		if filter.matches(task) {
+			fn(task)
+		}
+	}
+
+	if filter.IncludeDeleted {
+		for _, task := range s.deleted {
+			if filter.matches(task) {
+				fn(task)
+			}
+		}
+	}
+}
+
+// Find returns copies of the tasks matching filter.
+func (s *TaskStore) Find(filter Filter) []*models.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*models.Task, 0)
+	s.scan(filter, func(task *models.Task) {
+		taskCopy := *task
+		tasks = append(tasks, &taskCopy)
+	})
+
+	return tasks
+}
+
+// FindMap returns copies of the tasks matching filter keyed by id.
+func (s *TaskStore) FindMap(filter Filter) map[int]*models.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make(map[int]*models.Task)
+	s.scan(filter, func(task *models.Task) {
+		taskCopy := *task
+		tasks[task.ID] = &taskCopy
+	})
+
+	return tasks
+}
+
+// GetPaged returns copies of at most limit tasks matching filter, ordered by
+// order and skipping the first offset, together with the total number of
+// matching tasks.
+func (s *TaskStore) GetPaged(filter Filter, order Sort, limit, offset int) ([]*models.Task, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]*models.Task, 0)
+	s.scan(filter, func(task *models.Task) {
+		matched = append(matched, task)
+	})
+	order.apply(matched)
+
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit >= 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	tasks := make([]*models.Task, 0, end-offset)
+	for _, task := range matched[offset:end] {
+		taskCopy := *task
+		tasks = append(tasks, &taskCopy)
+	}
+
+	return tasks, total
+}
+
+// ForEach calls fn with a copy of each task while holding the read lock,
+// stopping early when fn returns false. Iteration order is unspecified. fn
+// must not call back into the store's mutating methods.
+func (s *TaskStore) ForEach(fn func(task *models.Task) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, task := range s.tasks {
+		taskCopy := *task
+		if !fn(&taskCopy) {
+			return
+		}
+	}
+}
+
+// Random returns a randomly chosen task, only considering pending tasks when
+// pendingOnly is set. Candidates are ordered by id before the pick, so a
+// seeded source (see WithRand) picks the same task from the same contents.
+// It returns ErrTaskNotFound if no task qualifies.
+func (s *TaskStore) Random(pendingOnly bool) (*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.tasks))
+	for id, task := range s.tasks {
+		if !pendingOnly || !task.Done {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no task to pick: %w", ErrTaskNotFound)
+	}
+	slices.Sort(ids)
+
+	s.randMu.Lock()
+	n := s.rand.IntN(len(ids))
+	s.randMu.Unlock()
+
+	taskCopy := *s.tasks[ids[n]]
+	return &taskCopy, nil
+}
+
+// Count returns the number of tasks matching filter without copying them.
+func (s *TaskStore) Count(filter Filter) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	s.scan(filter, func(*models.Task) {
+		count++
+	})
+
+	return count
+}
+
 func (s *TaskStore) Update(id int, done bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	task, exists := s.tasks[id]
 	if !exists {
-		return ErrTaskNotFound
+		return notFound(id)
 	}
 
 	task.Done = done
+	s.touch(task)
+	s.changed()
+	return nil
+}
+
+// TaskPatch lists the fields to change on a task. Nil fields are left alone.
+type TaskPatch struct {
+	Title       *string
+	Done        *bool
+	Priority    *string
+	Description *string
+
+	// IfVersion, when non-zero, makes the patch conditional: it is applied
+	// only if the task is still at that version, and ErrVersionConflict is
+	// returned otherwise. Clients use it for read-modify-write cycles that
+	// must not overwrite a concurrent change.
+	IfVersion int
+}
+
+// Patch applies the non-nil fields of patch to the task.
+func (s *TaskStore) Patch(id int, patch TaskPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.patch(id, patch); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// patch applies patch to the task with the given id. Callers must hold the
+// write lock and call changed afterwards.
+func (s *TaskStore) patch(id int, patch TaskPatch) error {
+	task, exists := s.tasks[id]
+	if !exists {
+		return notFound(id)
+	}
+	if patch.IfVersion != 0 && task.Version != patch.IfVersion {
+		return fmt.Errorf("task %d is at version %d, not %d: %w", id, task.Version, patch.IfVersion, ErrVersionConflict)
+	}
+
+	if patch.Title != nil {
+		task.Title = *patch.Title
+	}
+	if patch.Done != nil {
+		task.Done = *patch.Done
+	}
+	if patch.Priority != nil {
+		task.Priority = *patch.Priority
+	}
+	if patch.Description != nil {
+		task.Description = *patch.Description
+	}
+	s.touch(task)
 	return nil
 }
 
+// Upsert applies patch to the task with the given id or, if there is no such
+// task, creates draft under that id, as one atomic step. Later creates get
+// ids above it. A conditional patch never creates, and the id of a
+// soft-deleted task gives ErrIDTaken; an id outside 1..MaxUpsertID gives
+// ErrInvalidID. It returns a copy of the resulting task and whether it was
+// created.
+func (s *TaskStore) Upsert(id int, patch TaskPatch, draft models.Task) (*models.Task, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.tasks[id]
+	if !exists && patch.IfVersion == 0 {
+		if id < 1 || id > MaxUpsertID {
+			return nil, false, fmt.Errorf("task %d: %w", id, ErrInvalidID)
+		}
+		if _, deleted := s.deleted[id]; deleted {
+			return nil, false, fmt.Errorf("task %d is deleted: %w", id, ErrIDTaken)
+		}
+
+		now := s.clock.Now()
+		task := &draft
+		task.ID = id
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		task.Version = 1
+		applyDefaults(task)
+		s.tasks[id] = task
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+		s.changed()
+
+		taskCopy := *task
+		return &taskCopy, true, nil
+	}
+
+	if err := s.patch(id, patch); err != nil {
+		return nil, false, err
+	}
+	s.changed()
+
+	taskCopy := *s.tasks[id]
+	return &taskCopy, false, nil
+}
+
+// VersionedUpdate is one item of UpdateManyOptimistic: the patch to apply
+// to task ID if it is still at Patch.IfVersion.
+type VersionedUpdate struct {
+	ID    int
+	Patch TaskPatch
+}
+
+// UpdateOutcome says what happened to one item of UpdateManyOptimistic.
+type UpdateOutcome string
+
+const (
+	UpdateApplied  UpdateOutcome = "applied"
+	UpdateConflict UpdateOutcome = "conflict"
+	UpdateNotFound UpdateOutcome = "not_found"
+)
+
+// UpdateResult reports the outcome for one task. Version is the task's
+// version after the update, or its current version on a conflict.
+type UpdateResult struct {
+	ID      int
+	Outcome UpdateOutcome
+	Version int
+}
+
+// UpdateManyOptimistic applies each update whose expected version still
+// matches, under a single lock. A stale or missing task only skips its own
+// update; the results are in the order of updates.
+func (s *TaskStore) UpdateManyOptimistic(updates []VersionedUpdate) []UpdateResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]UpdateResult, len(updates))
+	applied := false
+	for i, u := range updates {
+		result := UpdateResult{ID: u.ID}
+
+		err := s.patch(u.ID, u.Patch)
+		switch {
+		case errors.Is(err, ErrTaskNotFound):
+			result.Outcome = UpdateNotFound
+		case errors.Is(err, ErrVersionConflict):
+			result.Outcome = UpdateConflict
+			result.Version = s.tasks[u.ID].Version
+		default:
+			result.Outcome = UpdateApplied
+			result.Version = s.tasks[u.ID].Version
+			applied = true
+		}
+
+		results[i] = result
+	}
+
+	if applied {
+		s.changed()
+	}
+	return results
+}
+
+// touch stamps a modified task. Callers must hold the write lock.
+func (s *TaskStore) touch(task *models.Task) {
+	task.UpdatedAt = s.clock.Now()
+	task.Version++
+}
+
+// ReplaceTask overwrites both the title and done status of an existing task.
+func (s *TaskStore) ReplaceTask(id int, title string, done bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[id]
+	if !exists {
+		return notFound(id)
+	}
+
+	task.Title = title
+	task.Done = done
+	s.touch(task)
+	s.changed()
+	return nil
+}
+
+// Delete soft-deletes a task: it disappears from every lookup but can be
+// brought back with Restore.
 func (s *TaskStore) Delete(id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if _, exists := s.tasks[id]; !exists {
-		return ErrTaskNotFound
+		return notFound(id)
 	}
 
-	delete(s.tasks, id)
+	s.remove(id)
+	s.changed()
 	return nil
 }
+
+// remove soft-deletes a task and records its tombstone. Callers must hold
+// the write lock.
+func (s *TaskStore) remove(id int) {
+	s.bury(s.tasks[id], s.clock.Now())
+	delete(s.tasks, id)
+}
+
+// bury moves task into the soft-deleted set as of now. Callers must hold the
+// write lock and remove the task from s.tasks themselves.
+func (s *TaskStore) bury(task *models.Task, now time.Time) {
+	task.DeletedAt = &now
+	s.deleted[task.ID] = task
+	s.tombstones[task.ID] = now
+}
+
+// Restore undoes a soft delete and returns a copy of the restored task.
+// Restoring a task that isn't deleted is a no-op.
+func (s *TaskStore) Restore(id int) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task, exists := s.tasks[id]; exists {
+		taskCopy := *task
+		return &taskCopy, nil
+	}
+
+	task, exists := s.deleted[id]
+	if !exists {
+		return nil, notFound(id)
+	}
+
+	delete(s.deleted, id)
+	delete(s.tombstones, id)
+	task.DeletedAt = nil
+	s.touch(task)
+	s.tasks[id] = task
+	s.changed()
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+// GetModifiedSince returns copies of the tasks updated after since and the
+// ids of tasks deleted after since, both ordered by id.
+func (s *TaskStore) GetModifiedSince(since time.Time) ([]*models.Task, []int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*models.Task, 0)
+	for _, task := range s.tasks {
+		if task.UpdatedAt.After(since) {
+			taskCopy := *task
+			tasks = append(tasks, &taskCopy)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	deleted := make([]int, 0)
+	for id, at := range s.tombstones {
+		if at.After(since) {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Ints(deleted)
+
+	return tasks, deleted
+}
+
+// DeleteMany deletes every task in ids under a single lock and returns how
+// many were deleted along with the ids that did not exist.
+func (s *TaskStore) DeleteMany(ids []int) (int, []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	notFound := make([]int, 0)
+	for _, id := range ids {
+		if _, exists := s.tasks[id]; !exists {
+			notFound = append(notFound, id)
+			continue
+		}
+		s.remove(id)
+		deleted++
+	}
+
+	if deleted > 0 {
+		s.changed()
+	}
+	return deleted, notFound
+}
+
+// Merge folds the source task into the target atomically: the target keeps
+// its title, becomes done if either task was done, gains the source's tags,
+// and the source is deleted.
+func (s *TaskStore) Merge(sourceID, targetID int) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, exists := s.tasks[sourceID]
+	if !exists {
+		return nil, notFound(sourceID)
+	}
+
+	target, exists := s.tasks[targetID]
+	if !exists {
+		return nil, notFound(targetID)
+	}
+
+	target.Done = target.Done || source.Done
+	for _, tag := range source.Tags {
+		if !slices.Contains(target.Tags, tag) {
+			// Append to a fresh slice: copies handed out share the old one.
+			target.Tags = append(slices.Clip(target.Tags), tag)
+		}
+	}
+	s.touch(target)
+	s.remove(sourceID)
+	s.changed()
+
+	taskCopy := *target
+	return &taskCopy, nil
+}
+
+// Revision returns a counter that changes whenever the stored tasks change.
+func (s *TaskStore) Revision() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revision
+}
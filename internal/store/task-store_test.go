@@ -0,0 +1,128 @@
+package store
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"practice-one/internal/clock"
+)
+
+// TestGetAllConcurrentMutation reads the whole store while other goroutines
+// create, update and delete tasks. Run it with -race: GetAll must hold the
+// read lock for the full iteration and return copies callers can use freely.
+func TestGetAllConcurrentMutation(t *testing.T) {
+	s := NewTaskStore()
+	for i := 0; i < 100; i++ {
+		s.Create("seed")
+	}
+
+	const iterations = 500
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			s.Create("new")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			s.Update(i%100+1, i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			id := i%100 + 1
+			s.Delete(id)
+			s.Restore(id)
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				for _, task := range s.GetAll() {
+					// Writing to the copy must not race with the store.
+					task.Title = "mutated copy"
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got, want := len(s.GetAll()), 100+iterations; got != want {
+		t.Fatalf("GetAll returned %d tasks, want %d", got, want)
+	}
+	for _, task := range s.GetAll() {
+		if task.Title == "mutated copy" {
+			t.Fatalf("task %d was changed through a copy returned by GetAll", task.ID)
+		}
+	}
+}
+
+// TestTaskStoreClock checks that every timestamp the store sets comes from
+// the injected clock.
+func TestTaskStoreClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	s := NewTaskStore(WithClock(fake))
+
+	task := s.Create("write tests")
+	if !task.CreatedAt.Equal(start) || !task.UpdatedAt.Equal(start) {
+		t.Fatalf("created at %v, updated at %v; want both %v", task.CreatedAt, task.UpdatedAt, start)
+	}
+
+	fake.Advance(time.Hour)
+	if err := s.Update(task.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(start) {
+		t.Errorf("CreatedAt changed to %v on update", got.CreatedAt)
+	}
+	if want := start.Add(time.Hour); !got.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", got.UpdatedAt, want)
+	}
+}
+
+func TestStateHash(t *testing.T) {
+	s := NewTaskStore()
+	task := s.Create("write tests")
+	initial := StateHash(s)
+
+	s.GetAll()
+	s.GetByID(task.ID)
+	if got := StateHash(s); got != initial {
+		t.Fatal("hash changed without a mutation")
+	}
+
+	if err := s.Update(task.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if StateHash(s) == initial {
+		t.Fatal("hash unchanged after marking the task done")
+	}
+
+	if err := s.Update(task.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := StateHash(s); got != initial {
+		t.Error("undoing the change did not restore the hash")
+	}
+
+	other := NewTaskStore()
+	other.Create("write tests")
+	if StateHash(other) != initial {
+		t.Error("stores holding the same task hash differently")
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"time"
+
+	"practice-one/internal/models"
+)
+
+// TxStore is the view of the store available inside WithTransaction. All
+// reads see the transaction's own writes; nothing is visible to other
+// callers until the transaction commits.
+type TxStore interface {
+	Create(title string) *models.Task
+	GetByID(id int) (*models.Task, error)
+	Exists(id int) bool
+	GetAll() []*models.Task
+	Update(id int, done bool) error
+	Delete(id int) error
+}
+
+// WithTransaction runs fn while holding the write lock. fn works on a private
+// copy of the tasks; if it returns nil the copy replaces the store contents,
+// otherwise (or if fn panics) the store is left unchanged and the error is
+// returned.
+func (s *TaskStore) WithTransaction(fn func(tx TxStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &txStore{
+		tasks:  make(map[int]*models.Task, len(s.tasks)),
+		nextID: s.nextID,
+		now:    s.clock.Now(),
+	}
+	for id, task := range s.tasks {
+		taskCopy := *task
+		tx.tasks[id] = &taskCopy
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if tx.dirty {
+		for id, task := range s.tasks {
+			if _, kept := tx.tasks[id]; !kept {
+				s.bury(task, tx.now)
+			}
+		}
+		s.tasks = tx.tasks
+		s.nextID = tx.nextID
+		s.changed()
+	}
+
+	return nil
+}
+
+type txStore struct {
+	tasks  map[int]*models.Task
+	nextID int
+	now    time.Time
+	dirty  bool
+}
+
+func (tx *txStore) Create(title string) *models.Task {
+	task := &models.Task{
+		ID:        tx.nextID,
+		Title:     title,
+		CreatedAt: tx.now,
+		UpdatedAt: tx.now,
+		Version:   1,
+	}
+	applyDefaults(task)
+	tx.tasks[tx.nextID] = task
+	tx.nextID++
+	tx.dirty = true
+
+	taskCopy := *task
+	return &taskCopy
+}
+
+func (tx *txStore) GetByID(id int) (*models.Task, error) {
+	task, exists := tx.tasks[id]
+	if !exists {
+		return nil, notFound(id)
+	}
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (tx *txStore) Exists(id int) bool {
+	_, exists := tx.tasks[id]
+	return exists
+}
+
+func (tx *txStore) GetAll() []*models.Task {
+	tasks := make([]*models.Task, 0, len(tx.tasks))
+	for _, task := range tx.tasks {
+		taskCopy := *task
+		tasks = append(tasks, &taskCopy)
+	}
+
+	return tasks
+}
+
+func (tx *txStore) Update(id int, done bool) error {
+	task, exists := tx.tasks[id]
+	if !exists {
+		return notFound(id)
+	}
+
+	task.Done = done
+	task.UpdatedAt = tx.now
+	task.Version++
+	tx.dirty = true
+	return nil
+}
+
+func (tx *txStore) Delete(id int) error {
+	if _, exists := tx.tasks[id]; !exists {
+		return notFound(id)
+	}
+
+	delete(tx.tasks, id)
+	tx.dirty = true
+	return nil
+}
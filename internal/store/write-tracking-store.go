@@ -0,0 +1,95 @@
+package store
+
+import (
+	"sync/atomic"
+
+	"practice-one/internal/models"
+)
+
+// WriteTrackingStore wraps another Store and counts calls to its mutating
+// methods, whether or not they end up changing anything. It lets a
+// development guard notice handlers for safe methods (GET, HEAD) that write.
+//
+// Reads are delegated through the embedded Store; every write method must be
+// overridden here or it will go uncounted.
+type WriteTrackingStore struct {
+	Store
+
+	writes atomic.Uint64
+}
+
+var _ Store = (*WriteTrackingStore)(nil)
+
+func NewWriteTrackingStore(inner Store) *WriteTrackingStore {
+	return &WriteTrackingStore{Store: inner}
+}
+
+// Writes returns the number of write calls made so far.
+func (s *WriteTrackingStore) Writes() uint64 {
+	return s.writes.Load()
+}
+
+func (s *WriteTrackingStore) Create(title string) *models.Task {
+	s.writes.Add(1)
+	return s.Store.Create(title)
+}
+
+func (s *WriteTrackingStore) CreateTask(draft models.Task) *models.Task {
+	s.writes.Add(1)
+	return s.Store.CreateTask(draft)
+}
+
+func (s *WriteTrackingStore) CreateMany(drafts []models.Task) []*models.Task {
+	s.writes.Add(1)
+	return s.Store.CreateMany(drafts)
+}
+
+func (s *WriteTrackingStore) Update(id int, done bool) error {
+	s.writes.Add(1)
+	return s.Store.Update(id, done)
+}
+
+func (s *WriteTrackingStore) Patch(id int, patch TaskPatch) error {
+	s.writes.Add(1)
+	return s.Store.Patch(id, patch)
+}
+
+func (s *WriteTrackingStore) Upsert(id int, patch TaskPatch, draft models.Task) (*models.Task, bool, error) {
+	s.writes.Add(1)
+	return s.Store.Upsert(id, patch, draft)
+}
+
+func (s *WriteTrackingStore) UpdateManyOptimistic(updates []VersionedUpdate) []UpdateResult {
+	s.writes.Add(1)
+	return s.Store.UpdateManyOptimistic(updates)
+}
+
+func (s *WriteTrackingStore) ReplaceTask(id int, title string, done bool) error {
+	s.writes.Add(1)
+	return s.Store.ReplaceTask(id, title, done)
+}
+
+func (s *WriteTrackingStore) Delete(id int) error {
+	s.writes.Add(1)
+	return s.Store.Delete(id)
+}
+
+func (s *WriteTrackingStore) Restore(id int) (*models.Task, error) {
+	s.writes.Add(1)
+	return s.Store.Restore(id)
+}
+
+func (s *WriteTrackingStore) DeleteMany(ids []int) (int, []int) {
+	s.writes.Add(1)
+	return s.Store.DeleteMany(ids)
+}
+
+func (s *WriteTrackingStore) Merge(sourceID, targetID int) (*models.Task, error) {
+	s.writes.Add(1)
+	return s.Store.Merge(sourceID, targetID)
+}
+
+func (s *WriteTrackingStore) WithTransaction(fn func(tx TxStore) error) error {
+	s.writes.Add(1)
+	return s.Store.WithTransaction(fn)
+}